@@ -7,11 +7,14 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/example/XXXDONGXXX/internal/config"
+	"github.com/example/XXXDONGXXX/internal/httpclient"
 	"github.com/example/XXXDONGXXX/internal/logger"
+	"github.com/example/XXXDONGXXX/internal/metrics"
 	"github.com/example/XXXDONGXXX/internal/scheduler"
 	"github.com/example/XXXDONGXXX/internal/server"
 	"github.com/example/XXXDONGXXX/internal/worker"
@@ -43,6 +46,96 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// worker pools run on their own context so that queued and in-flight
+	// jobs can still finish after the shutdown signal
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	defer workerCancel()
+	pools := &worker.Pools{
+		MainInput:     make(chan worker.Job, cfgMgr.Config().Concurrency.InputChannelSize),
+		MainHighInput: make(chan worker.Job, cfgMgr.Config().Concurrency.InputChannelSize),
+		MainLowInput:  make(chan worker.Job, cfgMgr.Config().Concurrency.InputChannelSize),
+		DBInput:       make(chan worker.Job, cfgMgr.Config().Concurrency.DBChannelSize),
+		ExtInput:      make(chan worker.Job, cfgMgr.Config().Concurrency.ExternalChannelSize),
+		Async:         make(map[worker.Pool]worker.Queue),
+		Batch: map[worker.Pool]worker.BatchConfig{
+			worker.PoolDB: {
+				MaxItems: cfgMgr.Config().Concurrency.DBBatchSize,
+				MaxWait:  time.Duration(cfgMgr.Config().Concurrency.DBBatchWaitMs) * time.Millisecond,
+			},
+		},
+	}
+	bc := cfgMgr.Config().External.Breaker
+	targets := make([]string, 0, len(cfgMgr.Config().External.Targets))
+	for name := range cfgMgr.Config().External.Targets {
+		targets = append(targets, name)
+	}
+	pools.Breakers = worker.NewBreakers(targets, worker.BreakerConfig{
+		Window:         time.Duration(bc.WindowSec) * time.Second,
+		MinRequests:    bc.MinRequests,
+		FailureRate:    bc.FailureRate,
+		CoolDown:       time.Duration(bc.CoolDownSec) * time.Second,
+		HalfOpenProbes: bc.HalfOpenProbes,
+	}, nil)
+	metrics.SetBreakerStats(pools.Breakers.Stats)
+	limits := make(map[string]worker.LimiterConfig)
+	for name, t := range cfgMgr.Config().External.Targets {
+		limits[name] = worker.LimiterConfig{
+			Rate:    t.RatePerSec,
+			Burst:   t.Burst,
+			MaxWait: time.Duration(t.MaxWaitMs) * time.Millisecond,
+		}
+	}
+	pools.Limiters = worker.NewLimiters(limits, nil)
+	httpClient := httpclient.New(cfgMgr.Config(), lg)
+	for name, t := range cfgMgr.Config().External.Targets {
+		if t.BaseURL == "" {
+			continue
+		}
+		if _, err := worker.RegisterHTTPTarget(worker.DefaultRegistry, httpClient, name, t.BaseURL); err != nil {
+			log.Fatalf("failed to register http target: %v", err)
+		}
+	}
+	qc := cfgMgr.Config().Queue
+	for _, pool := range []worker.Pool{worker.PoolMain, worker.PoolDB, worker.PoolExternal} {
+		if qc.Type != "file" {
+			pools.Async[pool] = worker.ChanQueue(make(chan worker.Job, qc.MaxLen))
+			continue
+		}
+		q, err := worker.NewFileQueue(filepath.Join(cfgMgr.ResolvePath(qc.Dir), string(pool)), worker.FileQueueOptions{
+			VisibilityTimeout: time.Duration(qc.VisibilityTimeoutSec) * time.Second,
+			MaxAttempts:       qc.MaxAttempts,
+			MaxLen:            qc.MaxLen,
+			RetryDelay:        time.Duration(qc.RetryDelayMs) * time.Millisecond,
+			MaxRetryDelay:     time.Duration(qc.MaxRetryDelayMs) * time.Millisecond,
+			OnError: func(err error) {
+				lg.Errorf("%s job queue: %v", pool, err)
+			},
+		})
+		if err != nil {
+			log.Fatalf("failed to open %s job queue: %v", pool, err)
+		}
+		pools.Async[pool] = q
+	}
+	worker.StartMainWorkers(workerCtx, cfgMgr.Config().Concurrency.MainLogicWorkerCount, pools, lg)
+	worker.StartDBWorkers(workerCtx, cfgMgr.Config().Concurrency.DBWorkerCount, pools, lg)
+	worker.StartExternalWorkers(workerCtx, cfgMgr.Config().Concurrency.ExternalWorkerCount, pools, lg)
+	metrics.SetPoolStats(pools.Stats)
+	jobs := worker.NewJobStore(pools, time.Duration(cfgMgr.Config().Jobs.ResultTTLSec)*time.Second)
+	go jobs.Run(workerCtx)
+
+	// scheduler (jobs with a pool run on the workers above)
+	var sched *scheduler.Scheduler
+	if cfgMgr.Config().Scheduler.Enabled {
+		sched, err = scheduler.New(cfgMgr.Config(), lg)
+		if err != nil {
+			lg.Errorf("failed to init scheduler: %v", err)
+			sched = nil
+		} else {
+			sched.SetPools(pools)
+			sched.Start(ctx)
+		}
+	}
+
 	// config reload goroutine
 	if cfgMgr.Config().ConfigReload.Enabled {
 		go func() {
@@ -58,38 +151,39 @@ func main() {
 						lg.Errorf("config reload failed: %v", err)
 					})
 					lg.SetLevel(cfgMgr.Hot().LogLevel)
+					if sched != nil {
+						sched.Apply(cfgMgr.Hot().SchedulerJobs)
+					}
+					hot := cfgMgr.Hot()
+					for pool, n := range map[worker.Pool]int{
+						worker.PoolMain:     hot.MainWorkerCount,
+						worker.PoolDB:       hot.DBWorkerCount,
+						worker.PoolExternal: hot.ExternalWorkerCount,
+					} {
+						if err := pools.Resize(pool, n); err != nil {
+							lg.Errorf("resize %s worker pool: %v", pool, err)
+						}
+					}
 				}
 			}
 		}()
 	}
 
-	// worker pools
-	pools := &worker.Pools{
-		MainInput: make(chan worker.Job, cfgMgr.Config().Concurrency.InputChannelSize),
-		DBInput:   make(chan worker.Job, cfgMgr.Config().Concurrency.DBChannelSize),
-		ExtInput:  make(chan worker.Job, cfgMgr.Config().Concurrency.ExternalChannelSize),
-	}
-	worker.StartMainWorkers(ctx, cfgMgr.Config().Concurrency.MainLogicWorkerCount, pools, lg)
-	worker.StartDBWorkers(ctx, cfgMgr.Config().Concurrency.DBWorkerCount, pools, lg)
-	worker.StartExternalWorkers(ctx, cfgMgr.Config().Concurrency.ExternalWorkerCount, pools, lg)
-
-	// scheduler
-	if cfgMgr.Config().Scheduler.Enabled {
-		sched, err := scheduler.New(cfgMgr.Config(), lg)
-		if err != nil {
-			lg.Errorf("failed to init scheduler: %v", err)
-		} else {
-			sched.Start(ctx)
-		}
-	}
-
 	deps := server.Dependencies{
 		ConfigMgr: cfgMgr,
 		Logger:    lg,
 		Pools:     pools,
+		Scheduler: sched,
+		Jobs:      jobs,
 	}
 	router := server.NewRouter(deps)
 
+	// request contexts outlive the shutdown signal: queued sync jobs carry
+	// them and would be dropped as expired if they ended before
+	// srv.Shutdown has let in-flight requests finish
+	reqCtx, reqCancel := context.WithCancel(context.Background())
+	defer reqCancel()
+
 	hot := cfgMgr.Hot()
 	srv := &http.Server{
 		Addr:         cfgMgr.Config().Server.Address,
@@ -98,7 +192,7 @@ func main() {
 		WriteTimeout: time.Duration(hot.WriteTimeoutSec) * time.Second,
 		IdleTimeout:  time.Duration(hot.IdleTimeoutSec) * time.Second,
 		BaseContext: func(net.Listener) context.Context {
-			return ctx
+			return reqCtx
 		},
 	}
 
@@ -126,5 +220,16 @@ func main() {
 		lg.Errorf("server shutdown error: %v", err)
 		_ = srv.Close()
 	}
+	reqCancel()
+	if sched != nil {
+		if err := sched.Stop(shutdownCtx); err != nil {
+			lg.Errorf("scheduler shutdown error: %v", err)
+		}
+	}
+	if err := pools.Shutdown(shutdownCtx); err != nil {
+		lg.Errorf("worker pools shutdown error: %v", err)
+	}
+	jobs.Close()
+	workerCancel()
 	lg.Infof("XXXDONGXXX stopped")
 }
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 )
@@ -33,11 +35,101 @@ type ConcurrencyConfig struct {
 	InputChannelSize      int `json:"inputChannelSize"`
 	DBChannelSize         int `json:"dbChannelSize"`
 	ExternalChannelSize   int `json:"externalChannelSize"`
+	DBBatchSize           int `json:"dbBatchSize"`
+	DBBatchWaitMs         int `json:"dbBatchWaitMs"`
+}
+
+type SchedulerRetryConfig struct {
+	MaxAttempts      int     `json:"maxAttempts"`
+	InitialBackoffMs int     `json:"initialBackoffMs"`
+	MaxBackoffMs     int     `json:"maxBackoffMs"`
+	Multiplier       float64 `json:"multiplier"`
+	Jitter           float64 `json:"jitter"`
+}
+
+type SchedulerJobConfig struct {
+	Name        string               `json:"name"`
+	Spec        string               `json:"spec"`
+	Enabled     bool                 `json:"enabled"`
+	TimeoutSec  int                  `json:"timeoutSec"`
+	Type        string               `json:"type"`
+	Misfire     string               `json:"misfire"`
+	Concurrency string               `json:"concurrency"`
+	Retry       SchedulerRetryConfig `json:"retry"`
+	Pool        string               `json:"pool"`
+}
+
+type SchedulerLockConfig struct {
+	Type             string `json:"type"`
+	Name             string `json:"name"`
+	Path             string `json:"path"`
+	Driver           string `json:"driver"`
+	DSN              string `json:"dsn"`
+	RenewIntervalSec int    `json:"renewIntervalSec"`
 }
 
 type SchedulerConfig struct {
-	Timezone string `json:"timezone"`
-	Enabled  bool   `json:"enabled"`
+	Timezone    string               `json:"timezone"`
+	Enabled     bool                 `json:"enabled"`
+	StateFile   string               `json:"stateFile"`
+	HistorySize int                  `json:"historySize"`
+	HistoryFile string               `json:"historyFile"`
+	Lock        SchedulerLockConfig  `json:"lock"`
+	Jobs        []SchedulerJobConfig `json:"jobs"`
+}
+
+type QueueConfig struct {
+	Type                 string `json:"type"`
+	Dir                  string `json:"dir"`
+	MaxLen               int    `json:"maxLen"`
+	VisibilityTimeoutSec int    `json:"visibilityTimeoutSec"`
+	MaxAttempts          int    `json:"maxAttempts"`
+	RetryDelayMs         int    `json:"retryDelayMs"`
+	MaxRetryDelayMs      int    `json:"maxRetryDelayMs"`
+}
+
+type BreakerConfig struct {
+	WindowSec      int     `json:"windowSec"`
+	MinRequests    int     `json:"minRequests"`
+	FailureRate    float64 `json:"failureRate"`
+	CoolDownSec    int     `json:"coolDownSec"`
+	HalfOpenProbes int     `json:"halfOpenProbes"`
+}
+
+type HTTPClientConfig struct {
+	TimeoutMs        int `json:"timeoutMs"`
+	ConnectTimeoutMs int `json:"connectTimeoutMs"`
+	MaxAttempts      int `json:"maxAttempts"`
+	InitialBackoffMs int `json:"initialBackoffMs"`
+	MaxBackoffMs     int `json:"maxBackoffMs"`
+}
+
+type ExternalTargetConfig struct {
+	RatePerSec float64 `json:"ratePerSec"`
+	Burst      int     `json:"burst"`
+	MaxWaitMs  int     `json:"maxWaitMs"`
+	// BaseURL, when set, registers an external job type of the target's
+	// name that GETs paths below it.
+	BaseURL string `json:"baseUrl"`
+}
+
+type ExternalConfig struct {
+	Breaker BreakerConfig                   `json:"breaker"`
+	HTTP    HTTPClientConfig                `json:"http"`
+	Targets map[string]ExternalTargetConfig `json:"targets"`
+}
+
+type JobsConfig struct {
+	ResultTTLSec int `json:"resultTtlSec"`
+	// Types lists the job types clients may queue through the jobs API.
+	Types []string `json:"types"`
+}
+
+// AdminConfig guards the /admin endpoints. They are not mounted unless
+// Enabled, and then require "Authorization: Bearer <Token>".
+type AdminConfig struct {
+	Enabled bool   `json:"enabled"`
+	Token   string `json:"token"`
 }
 
 type ConfigReloadConfig struct {
@@ -50,17 +142,25 @@ type Config struct {
 	Logging      LoggingConfig      `json:"logging"`
 	Concurrency  ConcurrencyConfig  `json:"concurrency"`
 	Scheduler    SchedulerConfig    `json:"scheduler"`
+	Queue        QueueConfig        `json:"queue"`
+	Jobs         JobsConfig         `json:"jobs"`
+	External     ExternalConfig     `json:"external"`
+	Admin        AdminConfig        `json:"admin"`
 	ConfigReload ConfigReloadConfig `json:"configReload"`
 }
 
 type HotConfig struct {
 	// hot-reloadable fields
-	ReadTimeoutSec    int
-	WriteTimeoutSec   int
-	IdleTimeoutSec    int
-	RequestTimeoutSec int
-	MaxBodyBytes      int64
-	LogLevel          string
+	ReadTimeoutSec      int
+	WriteTimeoutSec     int
+	IdleTimeoutSec      int
+	RequestTimeoutSec   int
+	MaxBodyBytes        int64
+	LogLevel            string
+	SchedulerJobs       []SchedulerJobConfig
+	MainWorkerCount     int
+	DBWorkerCount       int
+	ExternalWorkerCount int
 }
 
 type Configger interface {
@@ -158,9 +258,150 @@ func validate(c *Config) error {
 	if c.Concurrency.ExternalChannelSize <= 0 {
 		c.Concurrency.ExternalChannelSize = 256
 	}
+	switch c.Queue.Type {
+	case "":
+		c.Queue.Type = "memory"
+	case "memory", "file":
+	default:
+		return fmt.Errorf("queue.type must be memory or file")
+	}
+	if c.Queue.Dir == "" {
+		c.Queue.Dir = "data/queue"
+	}
+	if c.Queue.MaxLen <= 0 {
+		c.Queue.MaxLen = 10000
+	}
+	if c.Queue.VisibilityTimeoutSec <= 0 {
+		c.Queue.VisibilityTimeoutSec = 300
+	}
+	if c.Queue.MaxAttempts <= 0 {
+		c.Queue.MaxAttempts = 5
+	}
+	if c.Queue.RetryDelayMs <= 0 {
+		c.Queue.RetryDelayMs = 1000
+	}
+	if c.Queue.MaxRetryDelayMs <= 0 {
+		c.Queue.MaxRetryDelayMs = 60000
+	}
+	if c.Jobs.ResultTTLSec <= 0 {
+		c.Jobs.ResultTTLSec = 600
+	}
+	if c.Jobs.Types == nil {
+		c.Jobs.Types = []string{"example"}
+	}
+	if slices.Contains(c.Jobs.Types, "func") {
+		return errors.New("jobs.types: func jobs cannot be queued through the API")
+	}
+	if b := c.External.Breaker; b.WindowSec < 0 || b.MinRequests < 0 || b.CoolDownSec < 0 || b.HalfOpenProbes < 0 ||
+		b.FailureRate < 0 || b.FailureRate > 1 {
+		return errors.New("external.breaker: values must be >= 0 and failureRate <= 1")
+	}
+	if c.External.HTTP.TimeoutMs <= 0 {
+		c.External.HTTP.TimeoutMs = 5000
+	}
+	if c.External.HTTP.ConnectTimeoutMs <= 0 {
+		c.External.HTTP.ConnectTimeoutMs = 2000
+	}
+	if c.External.HTTP.MaxAttempts <= 0 {
+		c.External.HTTP.MaxAttempts = 3
+	}
+	if c.External.HTTP.InitialBackoffMs <= 0 {
+		c.External.HTTP.InitialBackoffMs = 100
+	}
+	if c.External.HTTP.MaxBackoffMs <= 0 {
+		c.External.HTTP.MaxBackoffMs = 2000
+	}
+	for name, t := range c.External.Targets {
+		if t.RatePerSec <= 0 {
+			return fmt.Errorf("external.targets[%s]: ratePerSec must be > 0", name)
+		}
+		if t.Burst <= 0 {
+			t.Burst = max(1, int(t.RatePerSec))
+		}
+		if t.MaxWaitMs <= 0 {
+			t.MaxWaitMs = 1000
+		}
+		if t.BaseURL != "" {
+			// built-in worker job types; an HTTP target must not replace them
+			if name == "example" || name == "func" {
+				return fmt.Errorf("external.targets[%s]: name is a built-in job type and cannot have a baseUrl", name)
+			}
+			u, err := url.Parse(t.BaseURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("external.targets[%s]: baseUrl must be an http(s) URL", name)
+			}
+		}
+		c.External.Targets[name] = t
+	}
 	if c.Scheduler.Timezone == "" {
 		c.Scheduler.Timezone = "Asia/Seoul"
 	}
+	if c.Scheduler.StateFile == "" {
+		c.Scheduler.StateFile = filepath.Join(c.Logging.Dir, "scheduler_state.json")
+	}
+	if c.Scheduler.HistorySize <= 0 {
+		c.Scheduler.HistorySize = 50
+	}
+	switch c.Scheduler.Lock.Type {
+	case "", "none", "file", "postgres":
+	default:
+		return fmt.Errorf("scheduler.lock.type must be none, file or postgres")
+	}
+	if c.Scheduler.Lock.Name == "" {
+		c.Scheduler.Lock.Name = "xxxdongxxx-scheduler"
+	}
+	if c.Scheduler.Lock.Path == "" {
+		c.Scheduler.Lock.Path = filepath.Join(c.Logging.Dir, "scheduler.lock")
+	}
+	if c.Scheduler.Lock.Driver == "" {
+		c.Scheduler.Lock.Driver = "pgx"
+	}
+	if c.Scheduler.Lock.RenewIntervalSec <= 0 {
+		c.Scheduler.Lock.RenewIntervalSec = 10
+	}
+	seen := make(map[string]bool)
+	for i, j := range c.Scheduler.Jobs {
+		if j.Name == "" {
+			return fmt.Errorf("scheduler.jobs[%d].name required", i)
+		}
+		if seen[j.Name] {
+			return fmt.Errorf("scheduler.jobs: duplicate name %q", j.Name)
+		}
+		seen[j.Name] = true
+		if j.Spec == "" || j.Type == "" {
+			return fmt.Errorf("scheduler.jobs[%s]: spec and type required", j.Name)
+		}
+		if j.TimeoutSec < 0 {
+			return fmt.Errorf("scheduler.jobs[%s]: timeoutSec must be >= 0", j.Name)
+		}
+		switch j.Misfire {
+		case "", "skip", "run-once", "run-all":
+		default:
+			return fmt.Errorf("scheduler.jobs[%s]: misfire must be skip, run-once or run-all", j.Name)
+		}
+		switch j.Concurrency {
+		case "", "allow", "forbid", "replace":
+		default:
+			return fmt.Errorf("scheduler.jobs[%s]: concurrency must be allow, forbid or replace", j.Name)
+		}
+		if j.Retry.MaxAttempts < 0 || j.Retry.InitialBackoffMs < 0 || j.Retry.MaxBackoffMs < 0 {
+			return fmt.Errorf("scheduler.jobs[%s]: retry values must be >= 0", j.Name)
+		}
+		if j.Retry.Jitter < 0 || j.Retry.Jitter > 1 {
+			return fmt.Errorf("scheduler.jobs[%s]: retry.jitter must be between 0 and 1", j.Name)
+		}
+		switch j.Pool {
+		case "", "main", "db", "external":
+		default:
+			return fmt.Errorf("scheduler.jobs[%s]: pool must be main, db or external", j.Name)
+		}
+	}
+	if c.Admin.Token == "" {
+		c.Admin.Token = os.Getenv("ADMIN_TOKEN")
+	}
+	if c.Admin.Enabled && c.Admin.Token == "" {
+		return errors.New("admin.token (or ADMIN_TOKEN) required when admin.enabled is true")
+	}
 	if c.ConfigReload.IntervalMinutes <= 0 {
 		c.ConfigReload.IntervalMinutes = 10
 	}
@@ -169,12 +410,16 @@ func validate(c *Config) error {
 
 func extractHot(c Config) HotConfig {
 	return HotConfig{
-		ReadTimeoutSec:    c.Server.ReadTimeoutSec,
-		WriteTimeoutSec:   c.Server.WriteTimeoutSec,
-		IdleTimeoutSec:    c.Server.IdleTimeoutSec,
-		RequestTimeoutSec: c.Server.RequestTimeoutSec,
-		MaxBodyBytes:      c.Server.MaxRequestBodyBytes,
-		LogLevel:          c.Logging.Level,
+		ReadTimeoutSec:      c.Server.ReadTimeoutSec,
+		WriteTimeoutSec:     c.Server.WriteTimeoutSec,
+		IdleTimeoutSec:      c.Server.IdleTimeoutSec,
+		RequestTimeoutSec:   c.Server.RequestTimeoutSec,
+		MaxBodyBytes:        c.Server.MaxRequestBodyBytes,
+		LogLevel:            c.Logging.Level,
+		SchedulerJobs:       c.Scheduler.Jobs,
+		MainWorkerCount:     c.Concurrency.MainLogicWorkerCount,
+		DBWorkerCount:       c.Concurrency.DBWorkerCount,
+		ExternalWorkerCount: c.Concurrency.ExternalWorkerCount,
 	}
 }
 
@@ -220,6 +465,10 @@ func (m *Manager) ReloadIfNeeded(onError func(error)) {
 	m.cfg.Server.RequestTimeoutSec = cfg.Server.RequestTimeoutSec
 	m.cfg.Server.MaxRequestBodyBytes = cfg.Server.MaxRequestBodyBytes
 	m.cfg.Logging.Level = cfg.Logging.Level
+	m.cfg.Scheduler.Jobs = cfg.Scheduler.Jobs
+	m.cfg.Concurrency.MainLogicWorkerCount = cfg.Concurrency.MainLogicWorkerCount
+	m.cfg.Concurrency.DBWorkerCount = cfg.Concurrency.DBWorkerCount
+	m.cfg.Concurrency.ExternalWorkerCount = cfg.Concurrency.ExternalWorkerCount
 
 	m.hot = extractHot(m.cfg)
 	m.lastModTime = modTime
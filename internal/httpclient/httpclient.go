@@ -0,0 +1,148 @@
+// # 외부 호출용 HTTP 클라이언트 (타임아웃, 재시도, X-Request-Id 전파, 로깅, 호스트별 메트릭)
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/example/XXXDONGXXX/internal/config"
+	"github.com/example/XXXDONGXXX/internal/logger"
+	"github.com/example/XXXDONGXXX/internal/metrics"
+	"github.com/example/XXXDONGXXX/internal/txid"
+)
+
+// Client is the HTTP client for calls to downstream services, meant for
+// external pool handlers. Every attempt is bounded by the configured
+// timeout, logged and counted per host; idempotent requests that fail with
+// a network error or a 429/502/503/504 are retried with exponential backoff.
+type Client struct {
+	hc             *http.Client
+	log            *logger.Logger
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// New builds a Client from cfg.External.HTTP.
+func New(cfg config.Config, log *logger.Logger) *Client {
+	hc := cfg.External.HTTP
+	connect := time.Duration(hc.ConnectTimeoutMs) * time.Millisecond
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.DialContext = (&net.Dialer{Timeout: connect, KeepAlive: 30 * time.Second}).DialContext
+	tr.TLSHandshakeTimeout = connect
+	return &Client{
+		hc: &http.Client{
+			Transport: tr,
+			Timeout:   time.Duration(hc.TimeoutMs) * time.Millisecond,
+		},
+		log:            log,
+		maxAttempts:    max(hc.MaxAttempts, 1),
+		initialBackoff: time.Duration(hc.InitialBackoffMs) * time.Millisecond,
+		maxBackoff:     time.Duration(hc.MaxBackoffMs) * time.Millisecond,
+	}
+}
+
+// Get sends a GET request to url.
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do sends req like http.Client.Do. The request gets an X-Request-Id header
+// from its context's txid unless it already has one. Retries stop early when
+// the request's context ends; the response returned is that of the last
+// attempt.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	tx := txid.FromContext(ctx)
+	req = req.Clone(ctx)
+	if req.Header.Get("X-Request-Id") == "" && tx != "" {
+		req.Header.Set("X-Request-Id", tx)
+	}
+	host := req.URL.Host
+	target := req.Method + " " + req.URL.Scheme + "://" + host + req.URL.Path
+
+	attempts := 1
+	if replayable(req) {
+		attempts = c.maxAttempts
+	}
+	for n := 1; ; n++ {
+		if n > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		start := time.Now()
+		resp, err := c.hc.Do(req)
+		d := time.Since(start)
+		if err != nil {
+			metrics.ObserveHTTPClient(host, req.Method, 0, d)
+			c.log.Errorf("http %s failed in %s (attempt %d/%d, tx=%s): %v", target, d, n, attempts, tx, err)
+		} else {
+			metrics.ObserveHTTPClient(host, req.Method, resp.StatusCode, d)
+			c.log.Debugf("http %s -> %d in %s (attempt %d/%d, tx=%s)", target, resp.StatusCode, d, n, attempts, tx)
+		}
+		if n >= attempts || ctx.Err() != nil || !retryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		metrics.IncHTTPClientRetry(host)
+		t := time.NewTimer(c.backoff(n))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// replayable reports whether req may be sent more than once: its method is
+// idempotent and its body, if any, can be read again.
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before attempt n+1: InitialBackoff doubled per
+// attempt, ±20% jitter, capped at MaxBackoff.
+func (c *Client) backoff(n int) time.Duration {
+	f := float64(c.initialBackoff)
+	for i := 1; i < n; i++ {
+		f *= 2
+	}
+	f += f * 0.2 * (2*rand.Float64() - 1)
+	if c.maxBackoff > 0 && f > float64(c.maxBackoff) {
+		f = float64(c.maxBackoff)
+	}
+	return time.Duration(f)
+}
@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/XXXDONGXXX/internal/config"
+	"github.com/example/XXXDONGXXX/internal/logger"
+	"github.com/example/XXXDONGXXX/internal/txid"
+)
+
+func newTestClient(t *testing.T, hc config.HTTPClientConfig) *Client {
+	t.Helper()
+	lg, err := logger.New(t.TempDir(), "debug")
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+	t.Cleanup(lg.Close)
+	return New(config.Config{External: config.ExternalConfig{HTTP: hc}}, lg)
+}
+
+func TestClientRetriesIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	var gotID, gotBody atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID.Store(r.Header.Get("X-Request-Id"))
+		b, _ := io.ReadAll(r.Body)
+		gotBody.Store(string(b))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	c := newTestClient(t, config.HTTPClientConfig{TimeoutMs: 1000, MaxAttempts: 3, InitialBackoffMs: 1, MaxBackoffMs: 5})
+
+	ctx := txid.WithTxID(context.Background(), "tx-1")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL+"/items/1", bytes.NewReader([]byte("payload")))
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" || calls.Load() != 3 {
+		t.Fatalf("got %d %q after %d calls", resp.StatusCode, body, calls.Load())
+	}
+	if gotID.Load() != "tx-1" || gotBody.Load() != "payload" {
+		t.Fatalf("last attempt had X-Request-Id %q, body %q", gotID.Load(), gotBody.Load())
+	}
+}
+
+func TestClientDoesNotRetryPost(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	c := newTestClient(t, config.HTTPClientConfig{TimeoutMs: 1000, MaxAttempts: 3, InitialBackoffMs: 1})
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader([]byte("{}")))
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 1 {
+		t.Fatalf("got %d after %d calls, want 502 after 1", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+	c := newTestClient(t, config.HTTPClientConfig{TimeoutMs: 20, MaxAttempts: 2, InitialBackoffMs: 1})
+
+	start := time.Now()
+	if _, err := c.Get(context.Background(), srv.URL); err == nil {
+		t.Fatal("want timeout error")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("two timed-out attempts took %s", d)
+	}
+}
+
+func TestClientStopsRetryingWhenContextEnds(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	c := newTestClient(t, config.HTTPClientConfig{TimeoutMs: 1000, MaxAttempts: 5, InitialBackoffMs: 200})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Get(ctx, srv.URL); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("%d calls, want 1", calls.Load())
+	}
+}
@@ -6,7 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
-	"time"
+
+	"github.com/example/XXXDONGXXX/internal/clock"
 )
 
 type Level int
@@ -41,6 +42,7 @@ type Logger struct {
 	date     string
 	size     map[Level]int64
 	maxBytes int64
+	clock    clock.Clock
 }
 
 func New(dir string, levelStr string) (*Logger, error) {
@@ -53,11 +55,22 @@ func New(dir string, levelStr string) (*Logger, error) {
 		loggers:  make(map[Level]*log.Logger),
 		size:     make(map[Level]int64),
 		maxBytes: 1 << 30, // 1GB
+		clock:    clock.Real{},
 	}
-	l.date = time.Now().Format("20060102")
+	l.date = l.clock.Now().Format("20060102")
 	return l, nil
 }
 
+// SetClock replaces the time source used for daily rollover (tests).
+func (l *Logger) SetClock(c clock.Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closeAll()
+	l.clock = c
+	l.date = c.Now().Format("20060102")
+	l.size = make(map[Level]int64)
+}
+
 func (l *Logger) SetLevel(levelStr string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -82,7 +95,7 @@ func (l *Logger) logf(level Level, format string, args ...any) {
 }
 
 func (l *Logger) ensureLogger(level Level) *log.Logger {
-	today := time.Now().Format("20060102")
+	today := l.clock.Now().Format("20060102")
 	if today != l.date {
 		// day changed, reset
 		l.closeAll()
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/XXXDONGXXX/internal/clock"
+)
+
+func TestDailyRotationAtMidnight(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New(dir, "info")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer l.Close()
+
+	fc := clock.NewFake(time.Date(2026, 12, 31, 23, 59, 30, 0, time.Local))
+	l.SetClock(fc)
+
+	l.Infof("before midnight")
+	fc.Advance(time.Minute)
+	l.Infof("after midnight")
+	l.Close()
+
+	tests := []struct {
+		file string
+		want string
+		not  string
+	}{
+		{"20261231.info.log", "before midnight", "after midnight"},
+		{"20270101.info.log", "after midnight", "before midnight"},
+	}
+	for _, tt := range tests {
+		b, err := os.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatalf("read %s: %v", tt.file, err)
+		}
+		if !strings.Contains(string(b), tt.want) || strings.Contains(string(b), tt.not) {
+			t.Fatalf("%s: unexpected content %q", tt.file, b)
+		}
+	}
+}
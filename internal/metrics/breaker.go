@@ -0,0 +1,55 @@
+package metrics
+
+import (
+    "fmt"
+    "io"
+    "sync"
+)
+
+// BreakerStats is a point-in-time view of one circuit breaker. State is 0
+// closed, 1 open, 2 half-open.
+type BreakerStats struct {
+    Target string
+    State  int
+}
+
+var (
+    breakerRejected    = newValueVec()
+    breakerTransitions = newValueVec()
+
+    breakerStatsMu sync.Mutex
+    breakerStats   func() []BreakerStats
+)
+
+// SetBreakerStats sets the source of the breaker state gauge, read on every
+// scrape.
+func SetBreakerStats(fn func() []BreakerStats) {
+    breakerStatsMu.Lock()
+    defer breakerStatsMu.Unlock()
+    breakerStats = fn
+}
+
+// IncBreakerRejected counts a call refused by target's open breaker.
+func IncBreakerRejected(target string) {
+    breakerRejected.add(labels("target", target), 1)
+}
+
+// IncBreakerTransition counts target's breaker changing to state.
+func IncBreakerTransition(target, state string) {
+    breakerTransitions.add(labels("target", target, "state", state), 1)
+}
+
+func writeBreakerMetrics(w io.Writer) {
+    breakerStatsMu.Lock()
+    fn := breakerStats
+    breakerStatsMu.Unlock()
+    if fn != nil {
+        fmt.Fprintf(w, "# HELP xxxdongxxx_circuit_breaker_state Circuit breaker state (0 closed, 1 open, 2 half-open)\n")
+        fmt.Fprintf(w, "# TYPE xxxdongxxx_circuit_breaker_state gauge\n")
+        for _, s := range fn() {
+            fmt.Fprintf(w, "xxxdongxxx_circuit_breaker_state{%s} %d\n", labels("target", s.Target), s.State)
+        }
+    }
+    breakerRejected.write(w, "xxxdongxxx_circuit_breaker_rejected_total", "counter", "Calls refused by an open circuit breaker")
+    breakerTransitions.write(w, "xxxdongxxx_circuit_breaker_transitions_total", "counter", "Circuit breaker state changes")
+}
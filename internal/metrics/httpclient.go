@@ -0,0 +1,35 @@
+package metrics
+
+import (
+    "io"
+    "strconv"
+    "time"
+)
+
+var (
+    httpClientRequests = newValueVec()
+    httpClientRetries  = newValueVec()
+    httpClientDuration = newHistogramVec(defaultBuckets)
+)
+
+// ObserveHTTPClient records one outbound HTTP attempt to host. status 0
+// means the attempt failed without a response.
+func ObserveHTTPClient(host, method string, status int, d time.Duration) {
+    code := "error"
+    if status > 0 {
+        code = strconv.Itoa(status)
+    }
+    httpClientRequests.add(labels("host", host, "method", method, "code", code), 1)
+    httpClientDuration.observe(labels("host", host), d)
+}
+
+// IncHTTPClientRetry counts an outbound request to host sent again.
+func IncHTTPClientRetry(host string) {
+    httpClientRetries.add(labels("host", host), 1)
+}
+
+func writeHTTPClientMetrics(w io.Writer) {
+    httpClientRequests.write(w, "xxxdongxxx_http_client_requests_total", "counter", "Outbound HTTP attempts by host, method and status code")
+    httpClientRetries.write(w, "xxxdongxxx_http_client_retries_total", "counter", "Outbound HTTP requests retried")
+    httpClientDuration.write(w, "xxxdongxxx_http_client_duration_seconds", "Outbound HTTP attempt duration")
+}
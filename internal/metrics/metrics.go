@@ -59,5 +59,11 @@ func Handler() http.Handler {
         fmt.Fprintf(w, "# HELP xxxdongxxx_total_requests Total HTTP requests observed\n")
         fmt.Fprintf(w, "# TYPE xxxdongxxx_total_requests counter\n")
         fmt.Fprintf(w, "xxxdongxxx_total_requests %d\n", cnt)
+
+        writeSchedulerMetrics(w)
+        writeWorkerMetrics(w)
+        writeBreakerMetrics(w)
+        writeHTTPClientMetrics(w)
+        writeRateLimitMetrics(w)
     })
 }
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+    "io"
+    "time"
+)
+
+var (
+    rateLimited   = newValueVec()
+    rateLimitWait = newHistogramVec(defaultBuckets)
+)
+
+// IncRateLimited counts a job refused because target's token bucket would
+// have made it wait too long.
+func IncRateLimited(target string) {
+    rateLimited.add(labels("target", target), 1)
+}
+
+// ObserveRateLimitWait records a job waiting d for a token of target.
+func ObserveRateLimitWait(target string, d time.Duration) {
+    rateLimitWait.observe(labels("target", target), d)
+}
+
+func writeRateLimitMetrics(w io.Writer) {
+    rateLimited.write(w, "xxxdongxxx_rate_limit_rejected_total", "counter", "Jobs refused because a downstream rate limit would have made them wait too long")
+    rateLimitWait.write(w, "xxxdongxxx_rate_limit_wait_seconds", "Time jobs waited for a downstream rate limit token")
+}
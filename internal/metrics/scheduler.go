@@ -0,0 +1,35 @@
+package metrics
+
+import (
+    "io"
+    "time"
+)
+
+var (
+    jobRuns        = newValueVec()
+    jobRetries     = newValueVec()
+    jobDuration    = newHistogramVec(defaultBuckets)
+    jobLastSuccess = newValueVec()
+)
+
+// ObserveJobRun records one finished scheduled-job run. status is
+// "success" or "failure"; a failure is only recorded after the last retry.
+func ObserveJobRun(job, status string, d time.Duration) {
+    jobRuns.add(labels("job", job, "status", status), 1)
+    jobDuration.observe(labels("job", job), d)
+    if status == "success" {
+        jobLastSuccess.set(labels("job", job), float64(time.Now().Unix()))
+    }
+}
+
+// IncJobRetry counts a failed attempt that will be retried.
+func IncJobRetry(job string) {
+    jobRetries.add(labels("job", job), 1)
+}
+
+func writeSchedulerMetrics(w io.Writer) {
+    jobRuns.write(w, "xxxdongxxx_scheduler_job_runs_total", "counter", "Scheduled job runs by result")
+    jobRetries.write(w, "xxxdongxxx_scheduler_job_retries_total", "counter", "Scheduled job attempts that failed and were retried")
+    jobDuration.write(w, "xxxdongxxx_scheduler_job_duration_seconds", "Scheduled job run duration in seconds, including retries")
+    jobLastSuccess.write(w, "xxxdongxxx_scheduler_job_last_success_timestamp", "gauge", "Unix time of the last successful run")
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+    "fmt"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// default buckets in seconds, covering sub-millisecond work up to long batch jobs
+var defaultBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600}
+
+type histogram struct {
+    buckets []float64
+    counts  []uint64
+    sum     float64
+    count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+    return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+    for i, b := range h.buckets {
+        if v <= b {
+            h.counts[i]++
+        }
+    }
+    h.sum += v
+    h.count++
+}
+
+// write prints the _bucket/_sum/_count series. labels is either empty or a
+// rendered label list such as `job="daily"`.
+func (h *histogram) write(w io.Writer, name, labels string) {
+    sep := ""
+    if labels != "" {
+        sep = ","
+    }
+    for i, b := range h.buckets {
+        fmt.Fprintf(w, "%s_bucket{%s%sle=\"%g\"} %d\n", name, labels, sep, b, h.counts[i])
+    }
+    fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, h.count)
+    fmt.Fprintf(w, "%s_sum%s %.6f\n", name, braces(labels), h.sum)
+    fmt.Fprintf(w, "%s_count%s %d\n", name, braces(labels), h.count)
+}
+
+// histogramVec is a set of histograms keyed by rendered label list.
+type histogramVec struct {
+    mu      sync.Mutex
+    buckets []float64
+    m       map[string]*histogram
+}
+
+func newHistogramVec(buckets []float64) *histogramVec {
+    return &histogramVec{buckets: buckets, m: make(map[string]*histogram)}
+}
+
+func (v *histogramVec) observe(labels string, d time.Duration) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    h, ok := v.m[labels]
+    if !ok {
+        h = newHistogram(v.buckets)
+        v.m[labels] = h
+    }
+    h.observe(d.Seconds())
+}
+
+func (v *histogramVec) write(w io.Writer, name, help string) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+    fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+    for _, k := range sortedKeys(v.m) {
+        v.m[k].write(w, name, k)
+    }
+}
+
+// valueVec is a set of counter or gauge values keyed by rendered label list.
+type valueVec struct {
+    mu sync.Mutex
+    m  map[string]float64
+}
+
+func newValueVec() *valueVec {
+    return &valueVec{m: make(map[string]float64)}
+}
+
+func (v *valueVec) add(labels string, n float64) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    v.m[labels] += n
+}
+
+func (v *valueVec) set(labels string, n float64) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    v.m[labels] = n
+}
+
+func (v *valueVec) write(w io.Writer, name, typ, help string) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+    fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+    for _, k := range sortedKeys(v.m) {
+        fmt.Fprintf(w, "%s%s %s\n", name, braces(k), strconv.FormatFloat(v.m[k], 'f', -1, 64))
+    }
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels renders name/value pairs as `a="x",b="y"`.
+func labels(kv ...string) string {
+    var b strings.Builder
+    for i := 0; i+1 < len(kv); i += 2 {
+        if i > 0 {
+            b.WriteByte(',')
+        }
+        fmt.Fprintf(&b, "%s=\"%s\"", kv[i], labelEscaper.Replace(kv[i+1]))
+    }
+    return b.String()
+}
+
+func braces(labels string) string {
+    if labels == "" {
+        return ""
+    }
+    return "{" + labels + "}"
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+    "fmt"
+    "io"
+    "sort"
+    "sync"
+    "time"
+)
+
+// PoolStats is a point-in-time view of one worker pool.
+type PoolStats struct {
+    Pool     string
+    QueueLen int
+    QueueCap int
+    Workers  int
+    Busy     int
+}
+
+var (
+    workerPanics    = newValueVec()
+    workerProcessed = newValueVec()
+    workerFailed    = newValueVec()
+    workerRejected  = newValueVec()
+    workerExpired   = newValueVec()
+    workerBatches   = newValueVec()
+    workerBatched   = newValueVec()
+    workerWait      = newHistogramVec(defaultBuckets)
+    workerExec      = newHistogramVec(defaultBuckets)
+
+    poolStatsMu sync.Mutex
+    poolStats   func() []PoolStats
+)
+
+// SetPoolStats sets the source of the per-pool gauges, read on every scrape.
+func SetPoolStats(fn func() []PoolStats) {
+    poolStatsMu.Lock()
+    defer poolStatsMu.Unlock()
+    poolStats = fn
+}
+
+// ObserveWorkerJob records a job handled on pool. wait is the time spent in
+// the queue (zero when unknown), exec the time spent in the handler.
+func ObserveWorkerJob(pool, jobType string, ok bool, wait, exec time.Duration) {
+    l := labels("pool", pool, "type", jobType)
+    workerProcessed.add(l, 1)
+    if !ok {
+        workerFailed.add(l, 1)
+    }
+    if wait > 0 {
+        workerWait.observe(labels("pool", pool), wait)
+    }
+    workerExec.observe(l, exec)
+}
+
+// IncWorkerRejected counts a job turned away because its queue was full.
+func IncWorkerRejected(pool, jobType string) {
+    workerRejected.add(labels("pool", pool, "type", jobType), 1)
+}
+
+// IncWorkerExpired counts a job whose context ended while it was queued, so
+// it was dropped without running.
+func IncWorkerExpired(pool, jobType string) {
+    workerExpired.add(labels("pool", pool, "type", jobType), 1)
+}
+
+// IncWorkerBatch counts a batch of size jobs handled together on pool.
+func IncWorkerBatch(pool, jobType string, size int) {
+    l := labels("pool", pool, "type", jobType)
+    workerBatches.add(l, 1)
+    workerBatched.add(l, float64(size))
+}
+
+// IncWorkerPanic counts a job handler that panicked on pool.
+func IncWorkerPanic(pool, jobType string) {
+    workerPanics.add(labels("pool", pool, "type", jobType), 1)
+}
+
+func writeWorkerMetrics(w io.Writer) {
+    poolStatsMu.Lock()
+    fn := poolStats
+    poolStatsMu.Unlock()
+    if fn != nil {
+        stats := fn()
+        sort.Slice(stats, func(i, j int) bool { return stats[i].Pool < stats[j].Pool })
+        gauges := []struct {
+            name, help string
+            value      func(PoolStats) int
+        }{
+            {"xxxdongxxx_worker_queue_length", "Jobs waiting in the pool queue", func(s PoolStats) int { return s.QueueLen }},
+            {"xxxdongxxx_worker_queue_capacity", "Capacity of the pool queue", func(s PoolStats) int { return s.QueueCap }},
+            {"xxxdongxxx_worker_workers", "Workers serving the pool", func(s PoolStats) int { return s.Workers }},
+            {"xxxdongxxx_worker_busy_workers", "Workers currently running a job", func(s PoolStats) int { return s.Busy }},
+        }
+        for _, g := range gauges {
+            fmt.Fprintf(w, "# HELP %s %s\n", g.name, g.help)
+            fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+            for _, s := range stats {
+                fmt.Fprintf(w, "%s{%s} %d\n", g.name, labels("pool", s.Pool), g.value(s))
+            }
+        }
+    }
+
+    workerProcessed.write(w, "xxxdongxxx_worker_jobs_processed_total", "counter", "Jobs handled by the worker pools")
+    workerFailed.write(w, "xxxdongxxx_worker_jobs_failed_total", "counter", "Jobs whose handler returned an error")
+    workerRejected.write(w, "xxxdongxxx_worker_jobs_rejected_total", "counter", "Jobs rejected because the queue was full")
+    workerExpired.write(w, "xxxdongxxx_worker_jobs_expired_total", "counter", "Jobs dropped because their context ended while queued")
+    workerBatches.write(w, "xxxdongxxx_worker_batches_total", "counter", "Job batches handled by batch handlers")
+    workerBatched.write(w, "xxxdongxxx_worker_batched_jobs_total", "counter", "Jobs handled as part of a batch")
+    workerPanics.write(w, "xxxdongxxx_worker_panics_total", "counter", "Worker job handlers that panicked")
+    workerWait.write(w, "xxxdongxxx_worker_queue_wait_seconds", "Time jobs spent queued before a worker picked them up")
+    workerExec.write(w, "xxxdongxxx_worker_job_duration_seconds", "Job handler execution time")
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
     "context"
+    "crypto/subtle"
     "log"
     "net/http"
     "time"
@@ -11,6 +12,7 @@ import (
     "github.com/example/XXXDONGXXX/internal/metrics"
     "github.com/example/XXXDONGXXX/internal/response"
     "github.com/example/XXXDONGXXX/internal/txid"
+    "github.com/example/XXXDONGXXX/internal/worker"
 )
 
 type Middleware func(http.Handler) http.Handler
@@ -37,6 +39,43 @@ func TxID() Middleware {
     }
 }
 
+// Priority sets the worker priority used by jobs the request submits: the
+// X-Priority header (high, normal or low) when present, otherwise def. The
+// header is client-controlled, so it is capped at max: callers can always
+// lower their priority but only raise it up to what the route allows.
+// Applied again on a sub-route, the inner default and cap win.
+func Priority(def, max worker.Priority) Middleware {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            p := def
+            if h := r.Header.Get("X-Priority"); h != "" {
+                if hp, err := worker.ParsePriority(h); err == nil {
+                    p = hp.Cap(max)
+                }
+            }
+            r = r.WithContext(worker.WithPriority(r.Context(), p))
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+// BearerAuth rejects requests whose Authorization header is not
+// "Bearer <token>".
+func BearerAuth(token string) Middleware {
+    want := []byte("Bearer " + token)
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            got := []byte(r.Header.Get("Authorization"))
+            if token == "" || subtle.ConstantTimeCompare(got, want) != 1 {
+                w.Header().Set("WWW-Authenticate", "Bearer")
+                response.JSON(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid token", nil)
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
 type loggingResponseWriter struct {
     http.ResponseWriter
     status int
@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+func TestScheduleNext(t *testing.T) {
+	seoul := mustLoad(t, "Asia/Seoul")
+	ny := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			name: "daily 06:00",
+			spec: "0 6 * * *",
+			from: time.Date(2026, 3, 1, 6, 0, 0, 0, seoul),
+			want: []time.Time{
+				time.Date(2026, 3, 2, 6, 0, 0, 0, seoul),
+				time.Date(2026, 3, 3, 6, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "month rollover from the 31st",
+			spec: "0 6 31 * *",
+			from: time.Date(2026, 1, 31, 7, 0, 0, 0, seoul),
+			want: []time.Time{
+				time.Date(2026, 3, 31, 6, 0, 0, 0, seoul),
+				time.Date(2026, 5, 31, 6, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "year rollover",
+			spec: "0 6 1 1 *",
+			from: time.Date(2026, 12, 31, 23, 59, 59, 0, seoul),
+			want: []time.Time{
+				time.Date(2027, 1, 1, 6, 0, 0, 0, seoul),
+				time.Date(2028, 1, 1, 6, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "leap day",
+			spec: "0 0 29 2 *",
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, seoul),
+			want: []time.Time{
+				time.Date(2028, 2, 29, 0, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "weekly sunday, 7 alias",
+			spec: "0 6 * * 7",
+			from: time.Date(2026, 10, 17, 12, 0, 0, 0, seoul),
+			want: []time.Time{
+				time.Date(2026, 10, 18, 6, 0, 0, 0, seoul),
+				time.Date(2026, 10, 25, 6, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "day-of-month or day-of-week",
+			spec: "0 0 1 * mon",
+			from: time.Date(2026, 10, 27, 0, 0, 0, 0, seoul),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 0, 0, 0, 0, seoul),
+				time.Date(2026, 11, 2, 0, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "stepped day-of-month is still a star",
+			spec: "0 0 */2 * 1",
+			from: time.Date(2026, 10, 17, 0, 0, 0, 0, seoul),
+			want: []time.Time{
+				time.Date(2026, 10, 19, 0, 0, 0, 0, seoul),
+				time.Date(2026, 11, 9, 0, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "six fields with seconds step",
+			spec: "*/20 0 12 * * *",
+			from: time.Date(2026, 10, 17, 12, 0, 30, 0, seoul),
+			want: []time.Time{
+				time.Date(2026, 10, 17, 12, 0, 40, 0, seoul),
+				time.Date(2026, 10, 18, 12, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "descriptor",
+			spec: "@monthly",
+			from: time.Date(2026, 12, 15, 0, 0, 0, 0, seoul),
+			want: []time.Time{
+				time.Date(2027, 1, 1, 0, 0, 0, 0, seoul),
+				time.Date(2027, 2, 1, 0, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "every",
+			spec: "@every 90m",
+			from: time.Date(2026, 10, 17, 23, 0, 0, 500, seoul),
+			want: []time.Time{
+				time.Date(2026, 10, 18, 0, 30, 0, 0, seoul),
+				time.Date(2026, 10, 18, 2, 0, 0, 0, seoul),
+			},
+		},
+		{
+			name: "DST spring forward skips the missing hour",
+			spec: "30 2 * * *",
+			from: time.Date(2026, 3, 7, 3, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2026, 3, 9, 2, 30, 0, 0, ny),
+			},
+		},
+		{
+			name: "DST spring forward hourly",
+			spec: "0 * * * *",
+			from: time.Date(2026, 3, 8, 1, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 3, 0, 0, 0, ny),
+				time.Date(2026, 3, 8, 4, 0, 0, 0, ny),
+			},
+		},
+		{
+			name: "DST fall back fires once",
+			spec: "30 1 * * *",
+			from: time.Date(2026, 11, 1, 0, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 1, 30, 0, 0, ny),
+				time.Date(2026, 11, 2, 1, 30, 0, 0, ny),
+			},
+		},
+		{
+			name: "@every ignores DST",
+			spec: "@every 1h",
+			from: time.Date(2026, 11, 1, 0, 30, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 1, 30, 0, 0, ny),
+				time.Date(2026, 11, 1, 1, 30, 0, 0, ny).Add(time.Hour),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched, err := ParseSpec(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSpec(%q): %v", tt.spec, err)
+			}
+			cur := tt.from
+			for i, want := range tt.want {
+				cur = sched.Next(cur)
+				if !cur.Equal(want) {
+					t.Fatalf("next #%d: got %s, want %s", i+1, cur, want)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleNextNever(t *testing.T) {
+	sched, err := ParseSpec("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := sched.Next(time.Now()); !next.IsZero() {
+		t.Fatalf("expected no activation, got %s", next)
+	}
+}
+
+func TestParseSpecErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"@fortnightly",
+		"@every 10ms",
+		"@every soon",
+	} {
+		if _, err := ParseSpec(spec); err == nil {
+			t.Errorf("ParseSpec(%q): expected error", spec)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package scheduler
+
+import (
+	"errors"
+	"os"
+)
+
+var errFlockUnsupported = errors.New("file lock is not supported on this platform")
+
+func tryFlock(f *os.File) (bool, error) {
+	return false, errFlockUnsupported
+}
+
+func unflock(f *os.File) error {
+	return errFlockUnsupported
+}
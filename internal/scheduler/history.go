@@ -0,0 +1,161 @@
+// # 작업 실행 이력 (작업별 최근 N건, 선택적으로 파일에 기록)
+package scheduler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Run is one finished execution of a job.
+type Run struct {
+	Job        string    `json:"job"`
+	TxID       string    `json:"txId"`
+	Scheduled  time.Time `json:"scheduled"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	DurationMs int64     `json:"durationMs"`
+	Attempts   int       `json:"attempts"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+}
+
+const (
+	RunSuccess = "success"
+	RunFailure = "failure"
+)
+
+// history keeps the most recent runs per job. With a path, every run is
+// also appended to a JSON-lines file which is compacted on load.
+type history struct {
+	mu    sync.Mutex
+	limit int
+	runs  map[string][]Run
+	path  string
+	f     *os.File
+}
+
+func newHistory(limit int, path string) (*history, error) {
+	if limit < 1 {
+		limit = 1
+	}
+	h := &history{limit: limit, runs: make(map[string][]Run), path: path}
+	if path == "" {
+		return h, nil
+	}
+	if err := h.load(); err != nil {
+		return h, err
+	}
+	if err := h.compact(); err != nil {
+		return h, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return h, fmt.Errorf("open scheduler history: %w", err)
+	}
+	h.f = f
+	return h, nil
+}
+
+func (h *history) load() error {
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open scheduler history: %w", err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Run
+		if json.Unmarshal(sc.Bytes(), &r) != nil {
+			continue // torn write
+		}
+		h.push(r)
+	}
+	return sc.Err()
+}
+
+// compact rewrites the file with only the retained runs.
+func (h *history) compact() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, runs := range h.runs {
+		for _, r := range runs {
+			if err := enc.Encode(r); err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+func (h *history) push(r Run) {
+	runs := append(h.runs[r.Job], r)
+	if len(runs) > h.limit {
+		runs = runs[len(runs)-h.limit:]
+	}
+	h.runs[r.Job] = runs
+}
+
+func (h *history) add(r Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.push(r)
+	if h.f == nil {
+		return nil
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = h.f.Write(append(b, '\n'))
+	return err
+}
+
+// list returns the runs of a job, newest first.
+func (h *history) list(job string) []Run {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	runs := h.runs[job]
+	out := make([]Run, len(runs))
+	for i, r := range runs {
+		out[len(runs)-1-i] = r
+	}
+	return out
+}
+
+func (h *history) last(job string) (Run, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	runs := h.runs[job]
+	if len(runs) == 0 {
+		return Run{}, false
+	}
+	return runs[len(runs)-1], true
+}
+
+func (h *history) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.f != nil {
+		_ = h.f.Close()
+		h.f = nil
+	}
+}
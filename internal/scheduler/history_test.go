@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n := 0
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		n++
+	}
+	return n
+}
+
+func TestHistoryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC)
+
+	h, err := newHistory(2, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := h.add(Run{Job: "a", TxID: strconv.Itoa(i + 1), Start: start.Add(time.Duration(i) * time.Hour), Status: RunSuccess}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.add(Run{Job: "b", TxID: "b1", Start: start, Status: RunFailure, Error: "boom"}); err != nil {
+		t.Fatal(err)
+	}
+	runs := h.list("a")
+	if len(runs) != 2 || runs[0].TxID != "3" || runs[1].TxID != "2" {
+		t.Fatalf("list(a) = %+v, want runs 3 and 2, newest first", runs)
+	}
+	h.close()
+	// every run is appended; trimming happens on the next load
+	if n := countLines(t, path); n != 4 {
+		t.Fatalf("history file has %d lines, want 4", n)
+	}
+
+	// a torn last line is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"job":"a","txId":`)
+	f.Close()
+
+	h, err = newHistory(2, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.close()
+	runs = h.list("a")
+	if len(runs) != 2 || runs[0].TxID != "3" || !runs[0].Start.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("reloaded list(a) = %+v, want runs 3 and 2", runs)
+	}
+	if last, ok := h.last("b"); !ok || last.Error != "boom" || last.Status != RunFailure {
+		t.Fatalf("reloaded last(b) = %+v, %t", last, ok)
+	}
+	if n := countLines(t, path); n != 3 {
+		t.Fatalf("compacted history file has %d lines, want 3", n)
+	}
+}
@@ -0,0 +1,149 @@
+// # 스케줄러 작업 조회/즉시 실행/일시정지 API (admin 핸들러용)
+package scheduler
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrJobNotFound = errors.New("scheduler: job not found")
+	ErrJobRunning  = errors.New("scheduler: job is already running")
+	ErrNotRunning  = errors.New("scheduler: not running")
+	ErrNotLeader   = errors.New("scheduler: not the leader replica")
+)
+
+// JobInfo is a point-in-time view of a registered job.
+type JobInfo struct {
+	Name           string     `json:"name"`
+	Spec           string     `json:"spec"`
+	Type           string     `json:"type,omitempty"`
+	Paused         bool       `json:"paused"`
+	Running        int        `json:"running"`
+	NextRun        *time.Time `json:"nextRun,omitempty"`
+	LastRun        *time.Time `json:"lastRun,omitempty"`
+	LastTxID       string     `json:"lastTxId,omitempty"`
+	LastResult     string     `json:"lastResult,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	LastDurationMs int64      `json:"lastDurationMs"`
+}
+
+// Jobs lists all registered jobs ordered by name.
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]JobInfo, 0, len(s.entries))
+	for _, e := range s.entries {
+		out = append(out, s.info(e))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Job returns a single job by name.
+func (s *Scheduler) Job(name string) (JobInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return JobInfo{}, ErrJobNotFound
+	}
+	return s.info(e), nil
+}
+
+// info builds a JobInfo. Caller holds s.mu.
+func (s *Scheduler) info(e *entry) JobInfo {
+	ji := JobInfo{
+		Name:    e.job.Name,
+		Spec:    e.job.Spec,
+		Type:    e.typ,
+		Paused:  e.paused,
+		Running: len(e.running),
+	}
+	if !e.next.IsZero() && !e.paused {
+		next := e.next.In(s.tz)
+		ji.NextRun = &next
+	}
+	if last, ok := s.history.last(e.job.Name); ok {
+		start := last.Start.In(s.tz)
+		ji.LastRun = &start
+		ji.LastTxID = last.TxID
+		ji.LastResult = last.Status
+		ji.LastError = last.Error
+		ji.LastDurationMs = last.DurationMs
+	}
+	return ji
+}
+
+// History returns the retained runs of a job, newest first.
+func (s *Scheduler) History(name string) ([]Run, error) {
+	s.mu.Lock()
+	_, ok := s.entries[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return s.history.list(name), nil
+}
+
+// Trigger runs a job immediately, outside its schedule. The job's
+// concurrency policy still applies; forbid yields ErrJobRunning. With a
+// Locker only the leader runs jobs, so followers return ErrNotLeader.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if s.ctx == nil || s.stopped || s.ctx.Err() != nil {
+		return ErrNotRunning
+	}
+	if !s.isLeader() {
+		return ErrNotLeader
+	}
+	now := s.now()
+	if !s.admit(e, now) {
+		return ErrJobRunning
+	}
+	s.log.Infof("job %s triggered manually", name)
+	s.startRun(e, []time.Time{now})
+	return nil
+}
+
+// Pause stops scheduled runs of a job until Resume. In-flight runs and
+// manual triggers are not affected. The paused flag is kept in memory on
+// this replica only: it is lost on restart and does not reach the leader
+// when called on a follower.
+func (s *Scheduler) Pause(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if !e.paused {
+		e.paused = true
+		s.log.Infof("job %s paused", name)
+	}
+	return nil
+}
+
+// Resume re-enables a paused job from its next regular fire time; runs
+// skipped while paused are not caught up.
+func (s *Scheduler) Resume(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if e.paused {
+		e.paused = false
+		e.next = e.schedule.Next(s.now())
+		s.log.Infof("job %s resumed, next run at %s", name, e.next)
+		s.notify()
+	}
+	return nil
+}
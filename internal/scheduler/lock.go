@@ -0,0 +1,164 @@
+// # 다중 레플리카 환경에서 스케줄러 리더 선출 (파일 락 / Postgres advisory lock)
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+
+	"github.com/example/XXXDONGXXX/internal/config"
+	// registers the "pgx" database/sql driver used by lock.type=postgres
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Locker grants scheduler leadership across replicas. TryLock acquires the
+// lease, or renews it when already held, and reports whether this instance
+// holds it now. Unlock gives the lease up so another replica can take over.
+type Locker interface {
+	TryLock(ctx context.Context) (bool, error)
+	Unlock(ctx context.Context) error
+}
+
+// NewLocker builds the Locker selected by cfg.Type. An empty type or
+// "none" returns a nil Locker, meaning every instance runs jobs.
+func NewLocker(cfg config.SchedulerLockConfig) (Locker, error) {
+	switch cfg.Type {
+	case "", "none":
+		return nil, nil
+	case "file":
+		return NewFileLocker(cfg.Path), nil
+	case "postgres":
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = dsnFromEnv()
+		}
+		db, err := sql.Open(cfg.Driver, dsn)
+		if err != nil {
+			return nil, fmt.Errorf("scheduler lock: %w", err)
+		}
+		return NewPostgresLocker(db, cfg.Name), nil
+	default:
+		return nil, fmt.Errorf("scheduler lock: unknown type %q", cfg.Type)
+	}
+}
+
+// dsnFromEnv uses the same DB_* variables as docker-compose.yml.
+func dsnFromEnv() string {
+	port := os.Getenv("DB_PORT")
+	if port == "" {
+		port = "5432"
+	}
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), port, os.Getenv("DB_NAME"))
+}
+
+// FileLocker holds an exclusive flock on a file. The OS drops the lock when
+// the holder exits, so it only coordinates processes on the same host.
+type FileLocker struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+func NewFileLocker(path string) *FileLocker {
+	return &FileLocker{path: path}
+}
+
+func (l *FileLocker) TryLock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f != nil {
+		return true, nil
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return false, fmt.Errorf("open lock file: %w", err)
+	}
+	ok, err := tryFlock(f)
+	if err != nil || !ok {
+		_ = f.Close()
+		return false, err
+	}
+	_ = f.Truncate(0)
+	_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+	l.f = f
+	return true, nil
+}
+
+func (l *FileLocker) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := unflock(l.f)
+	_ = l.f.Close()
+	l.f = nil
+	return err
+}
+
+// PostgresLocker uses a session-level advisory lock on a dedicated
+// connection. The lock lives as long as that session, so renewal checks the
+// connection and a crashed leader releases it when its session ends.
+//
+// db must use a registered Postgres driver; this package registers "pgx".
+type PostgresLocker struct {
+	db   *sql.DB
+	key  int64
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewPostgresLocker derives the advisory lock key from name.
+func NewPostgresLocker(db *sql.DB, name string) *PostgresLocker {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return &PostgresLocker{db: db, key: int64(h.Sum64())}
+}
+
+func (l *PostgresLocker) TryLock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		var one int
+		err := l.conn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+		if err == nil {
+			return true, nil
+		}
+		// session is gone and the lock with it
+		_ = l.conn.Close()
+		l.conn = nil
+		return false, fmt.Errorf("advisory lock session lost: %w", err)
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("advisory lock conn: %w", err)
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&ok); err != nil {
+		_ = conn.Close()
+		return false, fmt.Errorf("pg_try_advisory_lock: %w", err)
+	}
+	if !ok {
+		_ = conn.Close()
+		return false, nil
+	}
+	l.conn = conn
+	return true, nil
+}
+
+func (l *PostgresLocker) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	_ = l.conn.Close()
+	l.conn = nil
+	return err
+}
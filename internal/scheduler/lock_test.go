@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/XXXDONGXXX/internal/clock"
+)
+
+func TestFileLocker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler.lock")
+	ctx := context.Background()
+	a, b := NewFileLocker(path), NewFileLocker(path)
+
+	if ok, err := a.TryLock(ctx); !ok || err != nil {
+		t.Fatalf("a.TryLock = %t, %v; want true", ok, err)
+	}
+	if ok, err := b.TryLock(ctx); ok || err != nil {
+		t.Fatalf("b.TryLock = %t, %v; want false while a holds the lock", ok, err)
+	}
+	// renewing a held lock keeps it
+	if ok, err := a.TryLock(ctx); !ok || err != nil {
+		t.Fatalf("a renew = %t, %v; want true", ok, err)
+	}
+	if err := a.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.TryLock(ctx); !ok || err != nil {
+		t.Fatalf("b.TryLock after release = %t, %v; want true", ok, err)
+	}
+	if ok, err := a.TryLock(ctx); ok || err != nil {
+		t.Fatalf("a.TryLock = %t, %v; want false while b holds the lock", ok, err)
+	}
+	if err := b.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeaderFailover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler.lock")
+	start := time.Date(2026, 10, 17, 5, 59, 0, 0, time.UTC)
+
+	type replica struct {
+		s      *Scheduler
+		cancel context.CancelFunc
+		runs   atomic.Int32
+	}
+	var replicas [2]*replica
+	var clocks [2]*clock.Fake
+	for i := range replicas {
+		s, fc := newTestScheduler(t, "UTC", start)
+		r := &replica{s: s}
+		s.SetLocker(NewFileLocker(path), time.Second)
+		if err := s.Register("job", "* * * * *", func(context.Context) error {
+			r.runs.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		replicas[i], clocks[i] = r, fc
+	}
+	for _, r := range replicas {
+		ctx, cancel := context.WithCancel(context.Background())
+		r.cancel = cancel
+		r.s.Start(ctx)
+	}
+	// each replica waits on its run loop timer and its lease ticker
+	for i, r := range replicas {
+		clocks[i].BlockUntil(2)
+		clocks[i].Advance(time.Minute)
+		clocks[i].BlockUntil(2)
+		waitIdle(t, r.s)
+	}
+	if a, b := replicas[0].runs.Load(), replicas[1].runs.Load(); a != 1 || b != 0 {
+		t.Fatalf("runs = %d, %d; want only the first replica to run", a, b)
+	}
+	if err := replicas[1].s.Trigger("job"); !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("Trigger on follower = %v, want ErrNotLeader", err)
+	}
+
+	// the leader stops and releases the lease; the other takes over on its
+	// next renewal
+	replicas[0].cancel()
+	if err := replicas[0].s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	clocks[1].Advance(time.Second)
+	deadline := time.Now().Add(5 * time.Second)
+	for !replicas[1].s.isLeader() {
+		if time.Now().After(deadline) {
+			t.Fatal("second replica did not take over")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clocks[1].BlockUntil(2)
+	clocks[1].Advance(time.Minute)
+	clocks[1].BlockUntil(2)
+	waitIdle(t, replicas[1].s)
+	replicas[1].cancel()
+	if err := replicas[1].s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := replicas[1].runs.Load(); n != 1 {
+		t.Fatalf("second replica ran %d times after failover, want 1", n)
+	}
+}
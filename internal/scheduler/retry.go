@@ -0,0 +1,59 @@
+// # 실패한 작업 재시도 정책 (지수 백오프 + jitter)
+package scheduler
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how a failed run is retried. The zero value runs
+// each job once.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt; values below 2 disable retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Multiplier grows the backoff after every attempt, default 2.
+	Multiplier float64
+	// Jitter randomises each backoff by up to ±Jitter (0..1) of its value.
+	Jitter float64
+	// Retryable reports whether err is worth another attempt. Nil retries
+	// every error.
+	Retryable func(err error) bool
+}
+
+func (p RetryPolicy) attempts() int {
+	return max(p.MaxAttempts, 1)
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// backoff returns the wait before attempt n+1, given that attempt n (1-based)
+// just failed.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.InitialBackoff
+	if d <= 0 {
+		d = time.Second
+	}
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 2
+	}
+	f := float64(d)
+	for i := 1; i < n; i++ {
+		f *= mult
+		if p.MaxBackoff > 0 && f > float64(p.MaxBackoff) {
+			f = float64(p.MaxBackoff)
+			break
+		}
+	}
+	if j := min(max(p.Jitter, 0), 1); j > 0 {
+		f += f * j * (2*rand.Float64() - 1)
+	}
+	if p.MaxBackoff > 0 && f > float64(p.MaxBackoff) {
+		f = float64(p.MaxBackoff)
+	}
+	return time.Duration(f)
+}
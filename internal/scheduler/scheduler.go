@@ -2,16 +2,109 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/example/XXXDONGXXX/internal/clock"
 	"github.com/example/XXXDONGXXX/internal/config"
 	"github.com/example/XXXDONGXXX/internal/logger"
+	"github.com/example/XXXDONGXXX/internal/metrics"
+	"github.com/example/XXXDONGXXX/internal/txid"
+	"github.com/example/XXXDONGXXX/internal/worker"
 )
 
+// JobFunc is the unit of work executed by the scheduler.
+type JobFunc func(ctx context.Context) error
+
+// MisfirePolicy decides what happens to runs that were missed because the
+// process was down, the wall clock jumped forward or a wakeup came late.
+type MisfirePolicy string
+
+const (
+	MisfireSkip    MisfirePolicy = "skip"
+	MisfireRunOnce MisfirePolicy = "run-once"
+	MisfireRunAll  MisfirePolicy = "run-all"
+)
+
+// ConcurrencyPolicy decides what happens when a job is due while a previous
+// run of it is still in progress.
+type ConcurrencyPolicy string
+
+const (
+	ConcurrencyAllow   ConcurrencyPolicy = "allow"
+	ConcurrencyForbid  ConcurrencyPolicy = "forbid"
+	ConcurrencyReplace ConcurrencyPolicy = "replace"
+)
+
+const (
+	// a run starting within misfireGrace of its fire time is on time
+	misfireGrace = time.Minute
+	// upper bound of missed runs replayed by MisfireRunAll
+	maxCatchUp = 100
+	// the run loop re-reads the wall clock at least this often so that
+	// forward clock jumps are noticed
+	maxSleep = time.Minute
+)
+
+// Job describes a scheduled job.
+type Job struct {
+	Name    string
+	Spec    string
+	Func    JobFunc
+	Timeout time.Duration
+	Misfire MisfirePolicy
+	// Concurrency defaults to ConcurrencyForbid.
+	Concurrency ConcurrencyPolicy
+	Retry       RetryPolicy
+	// Pool sends each attempt to a worker pool so it counts against that
+	// pool's worker limit. Empty runs it on its own goroutine.
+	Pool worker.Pool
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	next     time.Time
+	prev     time.Time
+	// typ is the job-type key for jobs managed by config; empty for jobs
+	// registered in code, which Apply never touches.
+	typ string
+	cfg config.SchedulerJobConfig
+	// running holds the cancel funcs of in-flight runs keyed by run id
+	running map[uint64]context.CancelFunc
+	paused  bool
+}
+
 type Scheduler struct {
-	tz   *time.Location
-	log  *logger.Logger
-	quit chan struct{}
+	tz      *time.Location
+	clock   clock.Clock
+	log     *logger.Logger
+	mu      sync.Mutex
+	entries map[string]*entry
+	types   map[string]JobFunc
+	state   *stateStore
+	history *history
+	wake    chan struct{}
+
+	pools *worker.Pools
+
+	locker     Locker
+	renewEvery time.Duration
+	leader     atomic.Bool
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+	runSeq  uint64
+	wg      sync.WaitGroup
+	// runs outlive the loop: they get runCtx, which Stop cancels only when
+	// its own deadline passes, and are tracked by runWG
+	runCtx    context.Context
+	runCancel context.CancelFunc
+	runWG     sync.WaitGroup
 }
 
 func New(cfg config.Config, log *logger.Logger) (*Scheduler, error) {
@@ -19,118 +112,601 @@ func New(cfg config.Config, log *logger.Logger) (*Scheduler, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Scheduler{
-		tz:   loc,
-		log:  log,
-		quit: make(chan struct{}),
-	}, nil
+	s := &Scheduler{
+		tz:      loc,
+		clock:   clock.Real{},
+		log:     log,
+		entries: make(map[string]*entry),
+		types:   make(map[string]JobFunc),
+		wake:    make(chan struct{}, 1),
+	}
+	s.state, err = loadState(cfg.Scheduler.StateFile)
+	if err != nil {
+		log.Errorf("scheduler: %v (starting without run history)", err)
+	}
+	s.history, err = newHistory(cfg.Scheduler.HistorySize, cfg.Scheduler.HistoryFile)
+	if err != nil {
+		log.Errorf("scheduler: %v (history kept in memory only)", err)
+	}
+	locker, err := NewLocker(cfg.Scheduler.Lock)
+	if err != nil {
+		return nil, err
+	}
+	s.SetLocker(locker, time.Duration(cfg.Scheduler.Lock.RenewIntervalSec)*time.Second)
+	s.RegisterType("daily", s.runDaily)
+	s.RegisterType("weekly", s.runWeekly)
+	s.RegisterType("monthly", s.runMonthly)
+	s.RegisterType("yearly", s.runYearly)
+	s.Apply(cfg.Scheduler.Jobs)
+	return s, nil
+}
+
+// RegisterType makes fn available to config-defined jobs under the given
+// job-type key. Call Apply afterwards to pick up jobs that reference it.
+func (s *Scheduler) RegisterType(key string, fn JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.types[key] = fn
 }
 
+// Apply reconciles config-defined jobs with the running set: new enabled
+// jobs are added, removed or disabled ones are dropped and changed ones are
+// rescheduled. Jobs registered in code are left alone.
+func (s *Scheduler) Apply(jobs []config.SchedulerJobConfig) {
+	want := make(map[string]config.SchedulerJobConfig, len(jobs))
+	for _, jc := range jobs {
+		if jc.Enabled {
+			want[jc.Name] = jc
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+
+	for name, e := range s.entries {
+		if e.typ == "" {
+			continue
+		}
+		if _, ok := want[name]; !ok {
+			delete(s.entries, name)
+			s.log.Infof("scheduler: removed job %s", name)
+		}
+	}
+
+	for name, jc := range want {
+		fn, ok := s.types[jc.Type]
+		if !ok {
+			s.log.Errorf("scheduler: job %s: unknown type %q", name, jc.Type)
+			continue
+		}
+		sched, err := ParseSpec(jc.Spec)
+		if err != nil {
+			s.log.Errorf("scheduler: job %s: %v", name, err)
+			continue
+		}
+		j := Job{
+			Name:        name,
+			Spec:        jc.Spec,
+			Func:        fn,
+			Timeout:     time.Duration(jc.TimeoutSec) * time.Second,
+			Misfire:     MisfirePolicy(jc.Misfire),
+			Concurrency: ConcurrencyPolicy(jc.Concurrency),
+			Retry: RetryPolicy{
+				MaxAttempts:    jc.Retry.MaxAttempts,
+				InitialBackoff: time.Duration(jc.Retry.InitialBackoffMs) * time.Millisecond,
+				MaxBackoff:     time.Duration(jc.Retry.MaxBackoffMs) * time.Millisecond,
+				Multiplier:     jc.Retry.Multiplier,
+				Jitter:         jc.Retry.Jitter,
+			},
+			Pool: worker.Pool(jc.Pool),
+		}
+
+		e, exists := s.entries[name]
+		switch {
+		case !exists:
+			s.entries[name] = &entry{
+				job:      j,
+				schedule: sched,
+				next:     s.firstFire(name, sched, now),
+				typ:      jc.Type,
+				cfg:      jc,
+				running:  make(map[uint64]context.CancelFunc),
+			}
+			s.log.Infof("scheduler: added job %s type=%s spec=%q", name, jc.Type, jc.Spec)
+		case e.typ == "":
+			s.log.Errorf("scheduler: job %s is registered in code, config entry ignored", name)
+		case e.job.Spec != j.Spec:
+			s.log.Infof("scheduler: rescheduled job %s spec=%q -> %q", name, e.job.Spec, j.Spec)
+			e.job, e.schedule, e.typ, e.cfg = j, sched, jc.Type, jc
+			e.next = sched.Next(now)
+		case e.cfg != jc:
+			s.log.Infof("scheduler: updated job %s type=%s timeout=%s misfire=%s concurrency=%s retry.maxAttempts=%d pool=%s",
+				name, jc.Type, j.Timeout, j.Misfire, j.Concurrency, j.Retry.MaxAttempts, j.Pool)
+			e.job, e.typ, e.cfg = j, jc.Type, jc
+		}
+	}
+	s.notify()
+}
+
+// Register schedules fn under name using a cron spec (see ParseSpec).
+func (s *Scheduler) Register(name, spec string, fn JobFunc) error {
+	return s.RegisterJob(Job{Name: name, Spec: spec, Func: fn})
+}
+
+// RegisterJob adds a job. It may be called before or after Start.
+func (s *Scheduler) RegisterJob(j Job) error {
+	if j.Name == "" {
+		return errors.New("scheduler: job name required")
+	}
+	if j.Func == nil {
+		return fmt.Errorf("scheduler: job %s: func required", j.Name)
+	}
+	switch j.Misfire {
+	case "", MisfireSkip, MisfireRunOnce, MisfireRunAll:
+	default:
+		return fmt.Errorf("scheduler: job %s: unknown misfire policy %q", j.Name, j.Misfire)
+	}
+	switch j.Concurrency {
+	case "", ConcurrencyAllow, ConcurrencyForbid, ConcurrencyReplace:
+	default:
+		return fmt.Errorf("scheduler: job %s: unknown concurrency policy %q", j.Name, j.Concurrency)
+	}
+	switch j.Pool {
+	case "", worker.PoolMain, worker.PoolDB, worker.PoolExternal:
+	default:
+		return fmt.Errorf("scheduler: job %s: unknown worker pool %q", j.Name, j.Pool)
+	}
+	sched, err := ParseSpec(j.Spec)
+	if err != nil {
+		return fmt.Errorf("scheduler: job %s: %w", j.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[j.Name]; ok {
+		return fmt.Errorf("scheduler: job %s already registered", j.Name)
+	}
+	s.entries[j.Name] = &entry{
+		job:      j,
+		schedule: sched,
+		next:     s.firstFire(j.Name, sched, s.now()),
+		running:  make(map[uint64]context.CancelFunc),
+	}
+	s.notify()
+	return nil
+}
+
+// firstFire resumes from the persisted last run when there is one, so runs
+// missed while the process was down are seen as due by runDue.
+func (s *Scheduler) firstFire(name string, sched Schedule, now time.Time) time.Time {
+	if last, ok := s.state.get(name); ok && last.Before(now) {
+		return sched.Next(last.In(s.tz))
+	}
+	return sched.Next(now)
+}
+
+// Remove unregisters a job. Runs already in progress are not interrupted.
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[name]; !ok {
+		return false
+	}
+	delete(s.entries, name)
+	s.notify()
+	return true
+}
+
+// notify wakes the run loop so it recomputes its sleep. Caller holds s.mu.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SetClock replaces the time source, mainly for tests. Pending fire times
+// are recomputed against the new clock. Call before Start.
+func (s *Scheduler) SetClock(c clock.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = c
+	now := s.now()
+	for name, e := range s.entries {
+		e.next = s.firstFire(name, e.schedule, now)
+	}
+}
+
+func (s *Scheduler) now() time.Time {
+	return s.clock.Now().In(s.tz)
+}
+
+// SetPools gives jobs with a Pool somewhere to run. Call before Start.
+func (s *Scheduler) SetPools(p *worker.Pools) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pools = p
+}
+
+// SetLocker enables leader election: only the instance holding the lock
+// runs jobs, the others keep their schedules advancing and retry every
+// renew interval. A nil Locker disables election. Call before Start.
+func (s *Scheduler) SetLocker(l Locker, renew time.Duration) {
+	if renew <= 0 {
+		renew = 10 * time.Second
+	}
+	s.locker = l
+	s.renewEvery = renew
+}
+
+// Start runs the scheduling loop until ctx is done or Stop is called.
+// Job runs carry ctx's values but not its cancellation, so runs already in
+// progress when ctx ends are left to Stop.
 func (s *Scheduler) Start(ctx context.Context) {
-	go s.loopDaily(ctx)
-	go s.loopWeekly(ctx)
-	go s.loopMonthly(ctx)
-	go s.loopYearly(ctx)
+	runCtx, runCancel := context.WithCancel(context.WithoutCancel(ctx))
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.ctx, s.cancel = ctx, cancel
+	s.runCtx, s.runCancel = runCtx, runCancel
+	// the loop computes its first sleep from scratch
+	select {
+	case <-s.wake:
+	default:
+	}
+	s.mu.Unlock()
+	if s.locker != nil {
+		// try once up front so a sole replica runs startup catch-ups
+		s.renewLease(ctx)
+		s.wg.Add(1)
+		go s.elect(ctx)
+	}
+	s.wg.Add(1)
+	go s.run(ctx)
+}
+
+func (s *Scheduler) isLeader() bool {
+	return s.locker == nil || s.leader.Load()
 }
 
-func (s *Scheduler) loopDaily(ctx context.Context) {
-	ticker := time.NewTicker(time.Minute)
+// elect renews the leader lease every renew interval. Once ctx is done it
+// waits for in-flight runs before releasing the lease, so another replica
+// cannot start the same jobs while they are still running here.
+func (s *Scheduler) elect(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := s.clock.NewTicker(s.renewEvery)
 	defer ticker.Stop()
-	var lastDay int
 	for {
 		select {
 		case <-ctx.Done():
-			s.log.Infof("daily scheduler stopping")
-			return
-		case <-ticker.C:
-			now := time.Now().In(s.tz)
-			if now.Hour() == 6 && now.Minute() == 0 && now.Day() != lastDay {
-				lastDay = now.Day()
-				s.log.Infof("running daily job at %s", now)
-				go s.runDaily(now)
+			s.runWG.Wait()
+			unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := s.locker.Unlock(unlockCtx); err != nil {
+				s.log.Errorf("scheduler: release leader lease: %v", err)
 			}
+			cancel()
+			s.leader.Store(false)
+			return
+		case <-ticker.C():
+			s.renewLease(ctx)
 		}
 	}
 }
 
-func (s *Scheduler) loopWeekly(ctx context.Context) {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-	var lastYearDay int
+func (s *Scheduler) renewLease(ctx context.Context) {
+	held, err := s.locker.TryLock(ctx)
+	if err != nil && ctx.Err() == nil {
+		s.log.Errorf("scheduler: leader lease: %v", err)
+	}
+	if s.leader.Swap(held) != held {
+		if held {
+			s.log.Infof("scheduler: acquired leadership")
+		} else {
+			s.log.Infof("scheduler: lost leadership")
+		}
+	}
+}
+
+// Stop ends the scheduling loop so no new runs start and waits for runs in
+// progress to finish. If ctx expires first their contexts are cancelled and
+// Stop returns an error without waiting further.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	runCancel := s.runCancel
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.history.close()
+		return nil
+	case <-ctx.Done():
+		if runCancel != nil {
+			runCancel()
+		}
+		return fmt.Errorf("scheduler: waiting for running jobs: %w", ctx.Err())
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer s.wg.Done()
 	for {
+		var timerC <-chan time.Time
+		var timer clock.Timer
+		if next := s.nextFire(); !next.IsZero() {
+			timer = s.clock.NewTimer(min(next.Sub(s.clock.Now()), maxSleep))
+			timerC = timer.C()
+		}
+
 		select {
 		case <-ctx.Done():
-			s.log.Infof("weekly scheduler stopping")
+			if timer != nil {
+				timer.Stop()
+			}
+			s.log.Infof("scheduler stopping")
 			return
-		case <-ticker.C:
-			now := time.Now().In(s.tz)
-			// 매주 일요일(Sunday) 06시 00분 체크
-			if now.Weekday() == time.Sunday && now.Hour() == 6 && now.Minute() == 0 &&
-				now.YearDay() != lastYearDay {
-				lastYearDay = now.YearDay()
-				s.log.Infof("running weekly job at %s", now)
-				go s.runWeekly(now)
+		case <-s.wake:
+			if timer != nil {
+				timer.Stop()
 			}
+		case <-timerC:
+			s.runDue(s.now())
 		}
 	}
 }
 
-func (s *Scheduler) loopMonthly(ctx context.Context) {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-	var lastMonth int
-	var lastYear int
-	for {
-		select {
-		case <-ctx.Done():
-			s.log.Infof("monthly scheduler stopping")
-			return
-		case <-ticker.C:
-			now := time.Now().In(s.tz)
-			if now.Day() == 1 && now.Hour() == 6 && now.Minute() == 0 &&
-				(now.Month() != time.Month(lastMonth) || now.Year() != lastYear) {
-				lastMonth = int(now.Month())
-				lastYear = now.Year()
-				s.log.Infof("running monthly job at %s", now)
-				go s.runMonthly(now)
+// nextFire returns the earliest pending activation, or zero if none.
+func (s *Scheduler) nextFire() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var earliest time.Time
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || e.next.Before(earliest) {
+			earliest = e.next
+		}
+	}
+	return earliest
+}
+
+// runDue starts every job whose fire time has passed. When more than one
+// run is due, or the only one is later than misfireGrace, the job's
+// misfire policy decides which of them actually run. A backward clock jump
+// simply delays the next run; already handled fire times never repeat.
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leader := s.isLeader()
+	for _, e := range s.entries {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+		if !leader || e.paused {
+			s.log.Debugf("job %s due at %s not run (leader=%t paused=%t)", e.job.Name, e.next, leader, e.paused)
+			e.prev = e.next
+			e.next = e.schedule.Next(now)
+			continue
+		}
+
+		var due []time.Time
+		for t := e.next; !t.IsZero() && !t.After(now); t = e.schedule.Next(t) {
+			due = append(due, t)
+			if len(due) == maxCatchUp {
+				break
 			}
 		}
+		e.prev = due[len(due)-1]
+		e.next = e.schedule.Next(now)
+		if err := s.state.set(e.job.Name, e.prev); err != nil {
+			s.log.Errorf("scheduler: save state for job %s: %v", e.job.Name, err)
+		}
+
+		runs := due
+		if len(due) > 1 || now.Sub(due[0]) > misfireGrace {
+			policy := e.job.Misfire
+			if policy == "" {
+				policy = MisfireSkip
+			}
+			switch policy {
+			case MisfireRunOnce:
+				runs = due[len(due)-1:]
+			case MisfireSkip:
+				runs = nil
+			}
+			s.log.Infof("job %s misfired: %d run(s) missed since %s, policy=%s",
+				e.job.Name, len(due), due[0], policy)
+		}
+		if len(runs) == 0 || !s.admit(e, runs[0]) {
+			continue
+		}
+		s.startRun(e, runs)
 	}
 }
 
-func (s *Scheduler) loopYearly(ctx context.Context) {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-	var lastYear int
-	for {
-		select {
-		case <-ctx.Done():
-			s.log.Infof("yearly scheduler stopping")
-			return
-		case <-ticker.C:
-			now := time.Now().In(s.tz)
-			if now.Month() == time.January && now.Day() == 1 && now.Hour() == 6 && now.Minute() == 0 &&
-				now.Year() != lastYear {
-				lastYear = now.Year()
-				s.log.Infof("running yearly job at %s", now)
-				go s.runYearly(now)
+// admit applies the job's concurrency policy to a new run. Caller holds s.mu.
+func (s *Scheduler) admit(e *entry, fire time.Time) bool {
+	if len(e.running) == 0 {
+		return true
+	}
+	switch e.job.Concurrency {
+	case ConcurrencyAllow:
+	case ConcurrencyReplace:
+		s.log.Infof("job %s: cancelling %d running instance(s)", e.job.Name, len(e.running))
+		for _, cancel := range e.running {
+			cancel()
+		}
+	default:
+		s.log.Infof("job %s still running, skipping run scheduled at %s", e.job.Name, fire)
+		return false
+	}
+	return true
+}
+
+// startRun runs the given fire times of e one after another on a new
+// goroutine tracked by s.wg and s.runWG. Caller holds s.mu.
+func (s *Scheduler) startRun(e *entry, runs []time.Time) {
+	if s.stopped {
+		return
+	}
+	runCtx, cancel := context.WithCancel(s.runCtx)
+	s.runSeq++
+	id := s.runSeq
+	e.running[id] = cancel
+	s.wg.Add(1)
+	s.runWG.Add(1)
+
+	s.log.Infof("running job %s scheduled at %s", e.job.Name, runs[0])
+	go func(j Job) {
+		defer s.wg.Done()
+		defer s.runWG.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(e.running, id)
+			s.mu.Unlock()
+			cancel()
+		}()
+		for _, fire := range runs {
+			if runCtx.Err() != nil {
+				return
 			}
+			s.runJob(runCtx, j, fire)
 		}
+	}(e.job)
+}
+
+// runJob executes one scheduled run, retrying per j.Retry. All attempts
+// share the run's txid and each gets its own j.Timeout.
+func (s *Scheduler) runJob(ctx context.Context, j Job, fire time.Time) {
+	tx := txid.NewID()
+	ctx = txid.WithTxID(ctx, tx)
+	maxAttempts := j.Retry.attempts()
+
+	start := s.clock.Now()
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		err = s.attempt(ctx, j)
+		if err == nil || attempt == maxAttempts || ctx.Err() != nil || !j.Retry.retryable(err) {
+			break
+		}
+		wait := j.Retry.backoff(attempt)
+		s.log.Errorf("job %s tx=%s attempt %d/%d failed: %v (retrying in %s)",
+			j.Name, tx, attempt, maxAttempts, err, wait)
+		metrics.IncJobRetry(j.Name)
+		if !s.sleep(ctx, wait) {
+			break
+		}
+	}
+	end := s.clock.Now()
+	dur := end.Sub(start)
+
+	run := Run{
+		Job:        j.Name,
+		TxID:       tx,
+		Scheduled:  fire,
+		Start:      start,
+		End:        end,
+		DurationMs: dur.Milliseconds(),
+		Attempts:   attempt,
+		Status:     RunSuccess,
+	}
+	if err != nil {
+		run.Status = RunFailure
+		run.Error = err.Error()
+	}
+	if herr := s.history.add(run); herr != nil {
+		s.log.Errorf("scheduler: record history for job %s: %v", j.Name, herr)
+	}
+	metrics.ObserveJobRun(j.Name, run.Status, dur)
+
+	if err != nil {
+		s.log.Criticalf("job %s tx=%s (scheduled %s) failed after %d attempt(s) in %s: %v",
+			j.Name, tx, fire, attempt, dur, err)
+		return
+	}
+	s.log.Infof("job %s tx=%s (scheduled %s) finished in %s", j.Name, tx, fire, dur)
+}
+
+func (s *Scheduler) attempt(ctx context.Context, j Job) error {
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+	if j.Pool == "" {
+		return j.Func(ctx)
+	}
+	return s.dispatch(ctx, j)
+}
+
+// dispatch runs j.Func on a worker of j.Pool and waits for it. It blocks
+// while the pool's queue is full rather than dropping the run.
+func (s *Scheduler) dispatch(ctx context.Context, j Job) error {
+	s.mu.Lock()
+	pools := s.pools
+	s.mu.Unlock()
+	if pools == nil {
+		return fmt.Errorf("scheduler: job %s: no worker pools for pool %q", j.Name, j.Pool)
+	}
+	res := make(chan worker.Result, 1)
+	job := worker.Job{
+		Type: worker.JobTypeFunc,
+		TxID: txid.FromContext(ctx),
+		Ctx:  ctx,
+		Input: worker.Func(func(ctx context.Context) (interface{}, error) {
+			return nil, j.Func(ctx)
+		}),
+		Result: res,
+	}
+	if err := pools.Enqueue(ctx, j.Pool, job); err != nil {
+		return fmt.Errorf("scheduler: job %s: enqueue on %s pool: %w", j.Name, j.Pool, err)
+	}
+	select {
+	case r := <-res:
+		return r.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sleep waits for d on s.clock; it returns false if ctx ends first.
+func (s *Scheduler) sleep(ctx context.Context, d time.Duration) bool {
+	t := s.clock.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C():
+		return true
 	}
 }
 
-func (s *Scheduler) runDaily(t time.Time) {
-	s.log.Infof("daily job executed at %s", t)
+func (s *Scheduler) runDaily(ctx context.Context) error {
+	s.log.Infof("daily job executed at %s", s.now())
+	return nil
 }
 
-func (s *Scheduler) runWeekly(t time.Time) {
-	s.log.Infof("weekly job executed at %s", t)
+func (s *Scheduler) runWeekly(ctx context.Context) error {
+	s.log.Infof("weekly job executed at %s", s.now())
+	return nil
 }
 
-func (s *Scheduler) runMonthly(t time.Time) {
-	s.log.Infof("monthly job executed at %s", t)
+func (s *Scheduler) runMonthly(ctx context.Context) error {
+	s.log.Infof("monthly job executed at %s", s.now())
+	return nil
 }
 
-func (s *Scheduler) runYearly(t time.Time) {
-	s.log.Infof("yearly job executed at %s", t)
+func (s *Scheduler) runYearly(ctx context.Context) error {
+	s.log.Infof("yearly job executed at %s", s.now())
+	return nil
 }
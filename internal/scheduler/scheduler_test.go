@@ -0,0 +1,526 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/XXXDONGXXX/internal/clock"
+	"github.com/example/XXXDONGXXX/internal/config"
+	"github.com/example/XXXDONGXXX/internal/logger"
+	"github.com/example/XXXDONGXXX/internal/txid"
+	"github.com/example/XXXDONGXXX/internal/worker"
+)
+
+func newTestScheduler(t *testing.T, tz string, now time.Time) (*Scheduler, *clock.Fake) {
+	t.Helper()
+	return newTestSchedulerConfig(t, config.SchedulerConfig{Timezone: tz}, now)
+}
+
+func newTestSchedulerConfig(t *testing.T, cfg config.SchedulerConfig, now time.Time) (*Scheduler, *clock.Fake) {
+	t.Helper()
+	lg, err := logger.New(t.TempDir(), "debug")
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+	t.Cleanup(lg.Close)
+	s, err := New(config.Config{Scheduler: cfg}, lg)
+	if err != nil {
+		t.Fatalf("scheduler: %v", err)
+	}
+	fc := clock.NewFake(now)
+	s.SetClock(fc)
+	return s, fc
+}
+
+// runFor starts s, advances the fake clock in steps and stops s once every
+// triggered run has finished.
+func runFor(t *testing.T, s *Scheduler, fc *clock.Fake, step time.Duration, steps int) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	for i := 0; i < steps; i++ {
+		fc.BlockUntil(1)
+		fc.Advance(step)
+	}
+	// the loop re-arms its timer only after starting due runs
+	fc.BlockUntil(1)
+	waitIdle(t, s)
+	cancel()
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+}
+
+func waitIdle(t *testing.T, s *Scheduler) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		busy := false
+		for _, j := range s.Jobs() {
+			busy = busy || j.Running > 0
+		}
+		if !busy {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("jobs still running")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerFiresInTimezone(t *testing.T) {
+	seoul := mustLoad(t, "Asia/Seoul")
+	ny := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name  string
+		tz    string
+		start time.Time
+		spec  string
+		steps int
+		want  []time.Time
+	}{
+		{
+			name:  "daily across midnight",
+			tz:    "Asia/Seoul",
+			start: time.Date(2026, 10, 17, 5, 58, 0, 0, seoul),
+			spec:  "0 6 * * *",
+			steps: 5,
+			want:  []time.Time{time.Date(2026, 10, 17, 6, 0, 0, 0, seoul)},
+		},
+		{
+			name:  "monthly at month rollover",
+			tz:    "Asia/Seoul",
+			start: time.Date(2026, 10, 31, 23, 58, 0, 0, seoul),
+			spec:  "0 0 1 * *",
+			steps: 5,
+			want:  []time.Time{time.Date(2026, 11, 1, 0, 0, 0, 0, seoul)},
+		},
+		{
+			name:  "yearly at year rollover",
+			tz:    "Asia/Seoul",
+			start: time.Date(2026, 12, 31, 23, 58, 0, 0, seoul),
+			spec:  "@yearly",
+			steps: 5,
+			want:  []time.Time{time.Date(2027, 1, 1, 0, 0, 0, 0, seoul)},
+		},
+		{
+			name:  "spring forward skips 02:30",
+			tz:    "America/New_York",
+			start: time.Date(2026, 3, 8, 1, 0, 0, 0, ny),
+			spec:  "30 2 * * *",
+			steps: 180,
+			want:  nil,
+		},
+		{
+			name:  "fall back runs 01:30 once",
+			tz:    "America/New_York",
+			start: time.Date(2026, 11, 1, 0, 0, 0, 0, ny),
+			spec:  "30 1 * * *",
+			steps: 180,
+			want:  []time.Time{time.Date(2026, 11, 1, 1, 30, 0, 0, ny)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fc := newTestScheduler(t, tt.tz, tt.start)
+			var fired atomic.Int32
+			if err := s.Register("job", tt.spec, func(ctx context.Context) error {
+				fired.Add(1)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			runFor(t, s, fc, time.Minute, tt.steps)
+
+			hist, _ := s.History("job")
+			if int(fired.Load()) != len(tt.want) || len(hist) != len(tt.want) {
+				t.Fatalf("got %d runs (%d in history), want %d", fired.Load(), len(hist), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := hist[len(hist)-1-i].Scheduled
+				if !got.Equal(want) {
+					t.Fatalf("run %d scheduled at %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerMisfireAfterClockJump(t *testing.T) {
+	seoul := mustLoad(t, "Asia/Seoul")
+
+	tests := []struct {
+		policy MisfirePolicy
+		want   int
+	}{
+		{MisfireSkip, 0},
+		{MisfireRunOnce, 1},
+		{MisfireRunAll, 3},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			s, fc := newTestScheduler(t, "Asia/Seoul", time.Date(2026, 10, 17, 5, 0, 0, 0, seoul))
+			var fired atomic.Int32
+			err := s.RegisterJob(Job{
+				Name:    "job",
+				Spec:    "0 6 * * *",
+				Misfire: tt.policy,
+				Func: func(ctx context.Context) error {
+					fired.Add(1)
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// one jump of three days: 06:00 on the 17th, 18th and 19th are missed
+			runFor(t, s, fc, 72*time.Hour, 1)
+			if got := int(fired.Load()); got != tt.want {
+				t.Fatalf("got %d runs, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerRetry(t *testing.T) {
+	seoul := mustLoad(t, "Asia/Seoul")
+	errTemporary := errors.New("temporary")
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantStatus   string
+	}{
+		{"succeeds after retries", []error{errTemporary, errTemporary, nil}, 3, RunSuccess},
+		{"gives up after max attempts", []error{errTemporary, errTemporary, errTemporary, nil}, 3, RunFailure},
+		{"non-retryable error", []error{errFatal, nil}, 1, RunFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fc := newTestScheduler(t, "Asia/Seoul", time.Date(2026, 10, 17, 5, 59, 59, 0, seoul))
+			var calls atomic.Int32
+			err := s.RegisterJob(Job{
+				Name: "job",
+				Spec: "0 6 * * *",
+				Retry: RetryPolicy{
+					MaxAttempts:    3,
+					InitialBackoff: time.Second,
+					Retryable:      func(err error) bool { return !errors.Is(err, errFatal) },
+				},
+				Func: func(ctx context.Context) error {
+					n := calls.Add(1)
+					return tt.errs[n-1]
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			s.Start(ctx)
+			fc.BlockUntil(1)
+			fc.Advance(time.Second)
+			fc.BlockUntil(1)
+			// every retry waits on a backoff timer next to the loop's own
+			for i := 1; i < tt.wantAttempts; i++ {
+				fc.BlockUntil(2)
+				fc.Advance(2 * time.Second)
+			}
+			waitIdle(t, s)
+			cancel()
+			if err := s.Stop(context.Background()); err != nil {
+				t.Fatalf("stop: %v", err)
+			}
+
+			hist, _ := s.History("job")
+			if len(hist) != 1 {
+				t.Fatalf("got %d runs in history, want 1", len(hist))
+			}
+			if int(calls.Load()) != tt.wantAttempts || hist[0].Attempts != tt.wantAttempts {
+				t.Fatalf("got %d calls (%d recorded), want %d", calls.Load(), hist[0].Attempts, tt.wantAttempts)
+			}
+			if hist[0].Status != tt.wantStatus {
+				t.Fatalf("status %q, want %q", hist[0].Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSchedulerDispatchesToPool(t *testing.T) {
+	seoul := mustLoad(t, "Asia/Seoul")
+	s, fc := newTestScheduler(t, "Asia/Seoul", time.Date(2026, 10, 17, 5, 59, 59, 0, seoul))
+
+	// a single-slot DB pool served by a stand-in worker
+	pools := &worker.Pools{DBInput: make(chan worker.Job, 1)}
+	s.SetPools(pools)
+	got := make(chan worker.Job, 1)
+	go func() {
+		job := <-pools.DBInput
+		got <- job
+		data, err := job.Input.(worker.Func)(job.Ctx)
+		job.Result <- worker.Result{Data: data, Err: err}
+	}()
+
+	var ctxTx string
+	err := s.RegisterJob(Job{
+		Name: "job",
+		Spec: "0 6 * * *",
+		Pool: worker.PoolDB,
+		Func: func(ctx context.Context) error {
+			ctxTx = txid.FromContext(ctx)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runFor(t, s, fc, time.Second, 1)
+
+	job := <-got
+	hist, _ := s.History("job")
+	if len(hist) != 1 || hist[0].Status != RunSuccess {
+		t.Fatalf("history = %+v, want one successful run", hist)
+	}
+	if job.Type != worker.JobTypeFunc || job.TxID == "" {
+		t.Fatalf("dispatched job type=%d tx=%q", job.Type, job.TxID)
+	}
+	if job.TxID != hist[0].TxID || ctxTx != hist[0].TxID {
+		t.Fatalf("txid job=%s ctx=%s history=%s, want all equal", job.TxID, ctxTx, hist[0].TxID)
+	}
+}
+
+func TestRegisterJobUnknownPool(t *testing.T) {
+	s, _ := newTestScheduler(t, "UTC", time.Now())
+	err := s.RegisterJob(Job{Name: "job", Spec: "@daily", Pool: "gpu", Func: func(context.Context) error { return nil }})
+	if err == nil {
+		t.Fatal("want error for unknown pool")
+	}
+}
+
+func TestRegisterAndRemove(t *testing.T) {
+	s, _ := newTestScheduler(t, "UTC", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	noop := func(context.Context) error { return nil }
+
+	if err := s.Register("job", "0 6 * * *", noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("job", "@hourly", noop); err == nil {
+		t.Fatal("want error for duplicate name")
+	}
+	if err := s.Register("bad", "0 6 * *", noop); err == nil {
+		t.Fatal("want error for invalid spec")
+	}
+	if err := s.Register("", "@daily", noop); err == nil {
+		t.Fatal("want error for empty name")
+	}
+	if jobs := s.Jobs(); len(jobs) != 1 || jobs[0].Name != "job" {
+		t.Fatalf("jobs = %+v, want only job", jobs)
+	}
+	if !s.Remove("job") {
+		t.Fatal("Remove(job) = false")
+	}
+	if s.Remove("job") {
+		t.Fatal("second Remove(job) = true")
+	}
+	if err := s.Register("job", "@hourly", noop); err != nil {
+		t.Fatalf("re-register after remove: %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	s, _ := newTestScheduler(t, "UTC", start)
+	noop := func(context.Context) error { return nil }
+	s.RegisterType("noop", noop)
+	if err := s.Register("code", "@hourly", noop); err != nil {
+		t.Fatal(err)
+	}
+
+	names := func() []string {
+		var out []string
+		for _, j := range s.Jobs() {
+			out = append(out, j.Name)
+		}
+		return out
+	}
+	next := func(name string) time.Time {
+		j, err := s.Job(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *j.NextRun
+	}
+
+	s.Apply([]config.SchedulerJobConfig{
+		{Name: "a", Spec: "0 6 * * *", Type: "noop", Enabled: true},
+		{Name: "b", Spec: "0 7 * * *", Type: "noop", Enabled: true},
+		{Name: "c", Spec: "0 8 * * *", Type: "noop", Enabled: false},
+		{Name: "d", Spec: "0 9 * * *", Type: "missing", Enabled: true},
+		{Name: "code", Spec: "0 10 * * *", Type: "noop", Enabled: true},
+	})
+	if got := fmt.Sprint(names()); got != "[a b code]" {
+		t.Fatalf("jobs after first apply = %s, want [a b code]", got)
+	}
+	if want := start.Add(6 * time.Hour); !next("a").Equal(want) {
+		t.Fatalf("a next = %s, want %s", next("a"), want)
+	}
+	if want := start.Add(time.Hour); !next("code").Equal(want) {
+		t.Fatalf("code job was changed by config: next = %s, want %s", next("code"), want)
+	}
+
+	// change a's spec and timeout, drop b, enable c
+	s.Apply([]config.SchedulerJobConfig{
+		{Name: "a", Spec: "30 6 * * *", Type: "noop", Enabled: true, TimeoutSec: 5},
+		{Name: "c", Spec: "0 8 * * *", Type: "noop", Enabled: true},
+	})
+	if got := fmt.Sprint(names()); got != "[a c code]" {
+		t.Fatalf("jobs after second apply = %s, want [a c code]", got)
+	}
+	if want := start.Add(6*time.Hour + 30*time.Minute); !next("a").Equal(want) {
+		t.Fatalf("a next = %s, want %s", next("a"), want)
+	}
+	s.mu.Lock()
+	timeout := s.entries["a"].job.Timeout
+	s.mu.Unlock()
+	if timeout != 5*time.Second {
+		t.Fatalf("a timeout = %s, want 5s", timeout)
+	}
+
+	s.Apply(nil)
+	if got := fmt.Sprint(names()); got != "[code]" {
+		t.Fatalf("jobs after empty apply = %s, want [code]", got)
+	}
+}
+
+// blockingJob returns a job func that reports each start on started and
+// blocks until release is closed or its context ends.
+func blockingJob(started chan<- int32, release <-chan struct{}, starts, cancelled *atomic.Int32) JobFunc {
+	return func(ctx context.Context) error {
+		started <- starts.Add(1)
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			cancelled.Add(1)
+			return ctx.Err()
+		}
+	}
+}
+
+func TestConcurrencyPolicy(t *testing.T) {
+	tests := []struct {
+		policy        ConcurrencyPolicy
+		wantStarts    int32
+		wantCancelled int32
+	}{
+		{ConcurrencyForbid, 1, 0},
+		{ConcurrencyReplace, 2, 1},
+		{ConcurrencyAllow, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			s, fc := newTestScheduler(t, "UTC", time.Date(2026, 10, 17, 5, 59, 0, 0, time.UTC))
+			started := make(chan int32, 10)
+			release := make(chan struct{})
+			var starts, cancelled atomic.Int32
+			err := s.RegisterJob(Job{
+				Name:        "job",
+				Spec:        "* * * * *",
+				Concurrency: tt.policy,
+				Func:        blockingJob(started, release, &starts, &cancelled),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			s.Start(ctx)
+			fc.BlockUntil(1)
+			fc.Advance(time.Minute)
+			<-started
+			// the second fire finds the first run still in progress
+			fc.BlockUntil(1)
+			fc.Advance(time.Minute)
+			fc.BlockUntil(1)
+			if tt.wantStarts > 1 {
+				<-started
+			}
+			close(release)
+			waitIdle(t, s)
+			cancel()
+			if err := s.Stop(context.Background()); err != nil {
+				t.Fatalf("stop: %v", err)
+			}
+			if starts.Load() != tt.wantStarts || cancelled.Load() != tt.wantCancelled {
+				t.Fatalf("starts=%d cancelled=%d, want %d and %d",
+					starts.Load(), cancelled.Load(), tt.wantStarts, tt.wantCancelled)
+			}
+		})
+	}
+}
+
+func TestStopWaitsForRuns(t *testing.T) {
+	s, fc := newTestScheduler(t, "UTC", time.Date(2026, 10, 17, 5, 59, 59, 0, time.UTC))
+	started := make(chan int32, 1)
+	release := make(chan struct{})
+	var starts, cancelled atomic.Int32
+	if err := s.Register("job", "0 6 * * *", blockingJob(started, release, &starts, &cancelled)); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+	<-started
+
+	// like main: the loop context ends before Stop is called
+	cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Stop(context.Background()) }()
+	select {
+	case err := <-stopped:
+		t.Fatalf("Stop returned %v while the run was in progress", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	hist, _ := s.History("job")
+	if cancelled.Load() != 0 || len(hist) != 1 || hist[0].Status != RunSuccess {
+		t.Fatalf("cancelled=%d history=%+v, want one successful run", cancelled.Load(), hist)
+	}
+	if err := s.Trigger("job"); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Trigger after Stop = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestStopCancelsRunsAtDeadline(t *testing.T) {
+	s, fc := newTestScheduler(t, "UTC", time.Date(2026, 10, 17, 5, 59, 59, 0, time.UTC))
+	started := make(chan int32, 1)
+	var starts, cancelled atomic.Int32
+	if err := s.Register("job", "0 6 * * *", blockingJob(started, nil, &starts, &cancelled)); err != nil {
+		t.Fatal(err)
+	}
+	s.Start(context.Background())
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop = %v, want deadline exceeded", err)
+	}
+	waitIdle(t, s)
+	if cancelled.Load() != 1 {
+		t.Fatalf("run was not cancelled after Stop's deadline")
+	}
+}
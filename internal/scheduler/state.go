@@ -0,0 +1,77 @@
+// # 작업별 마지막 실행 시각 기록 (재시작 후 누락 실행 판단용)
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// stateStore persists the last handled fire time of every job so that runs
+// missed while the process was down can be detected on startup.
+type stateStore struct {
+	mu       sync.Mutex
+	path     string
+	lastFire map[string]time.Time
+}
+
+type stateFile struct {
+	LastFire map[string]time.Time `json:"lastFire"`
+}
+
+// loadState reads path if it exists. An empty path keeps state in memory only.
+func loadState(path string) (*stateStore, error) {
+	st := &stateStore{path: path, lastFire: make(map[string]time.Time)}
+	if path == "" {
+		return st, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("read scheduler state: %w", err)
+	}
+	var f stateFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return st, fmt.Errorf("parse scheduler state: %w", err)
+	}
+	for k, v := range f.LastFire {
+		st.lastFire[k] = v
+	}
+	return st, nil
+}
+
+func (st *stateStore) get(name string) (time.Time, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	t, ok := st.lastFire[name]
+	return t, ok
+}
+
+// set records t and rewrites the state file atomically.
+func (st *stateStore) set(name string, t time.Time) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.lastFire[name] = t
+	if st.path == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(stateFile{LastFire: st.lastFire}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(st.path), 0o755); err != nil {
+		return err
+	}
+	tmp := st.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, st.path)
+}
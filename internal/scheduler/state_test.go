@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/XXXDONGXXX/internal/config"
+)
+
+func TestStatePersistsAcrossRestart(t *testing.T) {
+	seoul := mustLoad(t, "Asia/Seoul")
+	cfg := config.SchedulerConfig{
+		Timezone:  "Asia/Seoul",
+		StateFile: filepath.Join(t.TempDir(), "state.json"),
+	}
+
+	var fired atomic.Int32
+	job := func(policy MisfirePolicy) Job {
+		return Job{
+			Name:    "job",
+			Spec:    "0 6 * * *",
+			Misfire: policy,
+			Func: func(ctx context.Context) error {
+				fired.Add(1)
+				return nil
+			},
+		}
+	}
+
+	// first process runs 06:00 on the 17th and records it
+	s, fc := newTestSchedulerConfig(t, cfg, time.Date(2026, 10, 17, 5, 59, 59, 0, seoul))
+	if err := s.RegisterJob(job(MisfireRunOnce)); err != nil {
+		t.Fatal(err)
+	}
+	runFor(t, s, fc, time.Second, 1)
+	if fired.Load() != 1 {
+		t.Fatalf("got %d runs before restart, want 1", fired.Load())
+	}
+	st, err := loadState(cfg.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last, ok := st.get("job"); !ok || !last.Equal(time.Date(2026, 10, 17, 6, 0, 0, 0, seoul)) {
+		t.Fatalf("state file last fire = %s (%t), want 06:00 on the 17th", last, ok)
+	}
+	saved, err := os.ReadFile(cfg.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy MisfirePolicy
+		want   int
+	}{
+		{MisfireSkip, 0},
+		{MisfireRunOnce, 1},
+		{MisfireRunAll, 3},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			// restarted at noon on the 20th: 06:00 on the 18th, 19th and 20th were missed
+			fired.Store(0)
+			cfg := cfg
+			cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+			if err := os.WriteFile(cfg.StateFile, saved, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			s, fc := newTestSchedulerConfig(t, cfg, time.Date(2026, 10, 20, 12, 0, 0, 0, seoul))
+			if err := s.RegisterJob(job(tt.policy)); err != nil {
+				t.Fatal(err)
+			}
+			runFor(t, s, fc, time.Minute, 0)
+			if got := int(fired.Load()); got != tt.want {
+				t.Fatalf("got %d catch-up runs, want %d", got, tt.want)
+			}
+			j, _ := s.Job("job")
+			if want := time.Date(2026, 10, 21, 6, 0, 0, 0, seoul); !j.NextRun.Equal(want) {
+				t.Fatalf("next run %s, want %s", j.NextRun, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// # /admin/scheduler/jobs 스케줄러 관리 API
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/XXXDONGXXX/internal/response"
+	"github.com/example/XXXDONGXXX/internal/scheduler"
+)
+
+func schedulerRoutes(deps Dependencies) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/jobs", ListJobsHandler(deps))
+		r.Get("/jobs/{name}", GetJobHandler(deps))
+		r.Get("/jobs/{name}/history", JobHistoryHandler(deps))
+		r.Post("/jobs/{name}/trigger", TriggerJobHandler(deps))
+		r.Post("/jobs/{name}/pause", PauseJobHandler(deps))
+		r.Post("/jobs/{name}/resume", ResumeJobHandler(deps))
+	}
+}
+
+func ListJobsHandler(deps Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !schedulerEnabled(w, r, deps) {
+			return
+		}
+		response.JSON(w, r, http.StatusOK, "OK", "jobs", deps.Scheduler.Jobs())
+	}
+}
+
+func GetJobHandler(deps Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !schedulerEnabled(w, r, deps) {
+			return
+		}
+		info, err := deps.Scheduler.Job(chi.URLParam(r, "name"))
+		if err != nil {
+			response.ErrorJSON(w, r, schedulerError(err))
+			return
+		}
+		response.JSON(w, r, http.StatusOK, "OK", "job", info)
+	}
+}
+
+func JobHistoryHandler(deps Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !schedulerEnabled(w, r, deps) {
+			return
+		}
+		runs, err := deps.Scheduler.History(chi.URLParam(r, "name"))
+		if err != nil {
+			response.ErrorJSON(w, r, schedulerError(err))
+			return
+		}
+		response.JSON(w, r, http.StatusOK, "OK", "history", runs)
+	}
+}
+
+func TriggerJobHandler(deps Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !schedulerEnabled(w, r, deps) {
+			return
+		}
+		name := chi.URLParam(r, "name")
+		if err := deps.Scheduler.Trigger(name); err != nil {
+			response.ErrorJSON(w, r, schedulerError(err))
+			return
+		}
+		response.JSON(w, r, http.StatusAccepted, "ACCEPTED", "job triggered", nil)
+	}
+}
+
+func PauseJobHandler(deps Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !schedulerEnabled(w, r, deps) {
+			return
+		}
+		name := chi.URLParam(r, "name")
+		if err := deps.Scheduler.Pause(name); err != nil {
+			response.ErrorJSON(w, r, schedulerError(err))
+			return
+		}
+		info, _ := deps.Scheduler.Job(name)
+		response.JSON(w, r, http.StatusOK, "OK", "job paused", info)
+	}
+}
+
+func ResumeJobHandler(deps Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !schedulerEnabled(w, r, deps) {
+			return
+		}
+		name := chi.URLParam(r, "name")
+		if err := deps.Scheduler.Resume(name); err != nil {
+			response.ErrorJSON(w, r, schedulerError(err))
+			return
+		}
+		info, _ := deps.Scheduler.Job(name)
+		response.JSON(w, r, http.StatusOK, "OK", "job resumed", info)
+	}
+}
+
+func schedulerEnabled(w http.ResponseWriter, r *http.Request, deps Dependencies) bool {
+	if deps.Scheduler != nil {
+		return true
+	}
+	response.ErrorJSON(w, r, &response.AppError{
+		Code:       "SCHEDULER_DISABLED",
+		Message:    "scheduler is disabled",
+		HTTPStatus: http.StatusServiceUnavailable,
+	})
+	return false
+}
+
+func schedulerError(err error) *response.AppError {
+	switch {
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		return &response.AppError{Code: "NOT_FOUND", Message: "job not found", HTTPStatus: http.StatusNotFound, Err: err}
+	case errors.Is(err, scheduler.ErrJobRunning):
+		return &response.AppError{Code: "JOB_RUNNING", Message: "job is already running", HTTPStatus: http.StatusConflict, Err: err}
+	case errors.Is(err, scheduler.ErrNotLeader):
+		return &response.AppError{Code: "NOT_LEADER", Message: "scheduler is not the leader on this replica", HTTPStatus: http.StatusServiceUnavailable, Err: err}
+	case errors.Is(err, scheduler.ErrNotRunning):
+		return &response.AppError{Code: "SCHEDULER_DISABLED", Message: "scheduler is not running", HTTPStatus: http.StatusServiceUnavailable, Err: err}
+	default:
+		return &response.AppError{Code: "INTERNAL_ERROR", Message: "internal error", HTTPStatus: http.StatusInternalServerError, Err: err}
+	}
+}
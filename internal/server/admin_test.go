@@ -0,0 +1,113 @@
+// 스케줄러 admin 핸들러 테스트
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/XXXDONGXXX/internal/config"
+	"github.com/example/XXXDONGXXX/internal/scheduler"
+)
+
+const testAdminToken = "test-admin-token"
+
+func adminRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return req
+}
+
+func newSchedulerTestDeps(t *testing.T) Dependencies {
+	t.Helper()
+	deps := newTestDeps(t)
+	cfg := deps.ConfigMgr.Config()
+	cfg.Scheduler.Jobs = []config.SchedulerJobConfig{
+		{Name: "daily", Spec: "0 6 * * *", Enabled: true, Type: "daily"},
+	}
+	cfg.Admin = config.AdminConfig{Enabled: true, Token: testAdminToken}
+	deps.ConfigMgr = &config.ManagerMock{Cfg: cfg}
+	sched, err := scheduler.New(cfg, deps.Logger)
+	if err != nil {
+		t.Fatalf("scheduler: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sched.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		_ = sched.Stop(context.Background())
+	})
+	deps.Scheduler = sched
+	return deps
+}
+
+func TestSchedulerAdminJobs(t *testing.T) {
+	h := NewRouter(newSchedulerTestDeps(t))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/scheduler/jobs"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d", rec.Code)
+	}
+	var body struct {
+		Code string              `json:"code"`
+		Data []scheduler.JobInfo `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(body.Data) != 1 || body.Data[0].Name != "daily" || body.Data[0].NextRun == nil {
+		t.Fatalf("unexpected jobs: %+v", body.Data)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/scheduler/jobs/daily/pause"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pause: expected 200, got %d", rec.Code)
+	}
+	var paused struct {
+		Data scheduler.JobInfo `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &paused); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if !paused.Data.Paused || paused.Data.NextRun != nil {
+		t.Fatalf("expected paused job without next run, got %+v", paused.Data)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/scheduler/jobs/daily/trigger"))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("trigger: expected 202, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/scheduler/jobs/missing/resume"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("resume missing: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	h := NewRouter(newSchedulerTestDeps(t))
+	for _, auth := range []string{"", "Bearer wrong", testAdminToken} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/scheduler/jobs", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Authorization %q: expected 401, got %d", auth, rec.Code)
+		}
+	}
+
+	// admin is off by default
+	rec := httptest.NewRecorder()
+	NewRouter(newTestDeps(t)).ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/scheduler/jobs"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("admin disabled: expected 404, got %d", rec.Code)
+	}
+}
@@ -2,12 +2,13 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/example/XXXDONGXXX/internal/response"
-	"github.com/example/XXXDONGXXX/internal/txid"
 	"github.com/example/XXXDONGXXX/internal/worker"
 )
 
@@ -40,46 +41,53 @@ func EchoHandler(deps Dependencies) http.HandlerFunc {
 
 		// enforce max body size at handler-level if needed; main limit is via server
 
-		tx := txid.FromContext(r.Context())
-		resCh := make(chan worker.Result, 1)
-		job := worker.Job{
-			Type:   worker.JobTypeExample,
-			TxID:   tx,
-			Ctx:    r.Context(),
-			Input:  req,
-			Result: resCh,
-		}
-
-		select {
-		case deps.Pools.MainInput <- job:
-		default:
-			response.ErrorJSON(w, r, &response.AppError{
-				Code:       "BACKPRESSURE",
-				Message:    "server busy",
-				HTTPStatus: http.StatusServiceUnavailable,
-			})
+		out, err := worker.Submit[echoRequest, echoRequest](r.Context(), deps.Pools, worker.PoolMain, worker.JobTypeExample, req)
+		if err != nil {
+			response.ErrorJSON(w, r, workerError(err))
 			return
 		}
+		response.JSON(w, r, http.StatusOK, "OK", "echo", out)
+	}
+}
 
-		select {
-		case res := <-resCh:
-			if res.Err != nil {
-				response.ErrorJSON(w, r, &response.AppError{
-					Code:       "INTERNAL_ERROR",
-					Message:    "internal error",
-					HTTPStatus: http.StatusInternalServerError,
-					Err:        res.Err,
-				})
-				return
-			}
-			response.JSON(w, r, http.StatusOK, "OK", "echo", res.Data)
-		case <-r.Context().Done():
-			response.ErrorJSON(w, r, &response.AppError{
-				Code:       "REQUEST_TIMEOUT",
-				Message:    "request timeout",
-				HTTPStatus: http.StatusGatewayTimeout,
-				Err:        r.Context().Err(),
-			})
+// workerError maps worker.Submit errors to the API error codes.
+func workerError(err error) *response.AppError {
+	var circuitOpen *worker.CircuitOpenError
+	var rateLimited *worker.RateLimitError
+	switch {
+	case errors.As(err, &circuitOpen):
+		return &response.AppError{
+			Code:       "DEPENDENCY_UNAVAILABLE",
+			Message:    "dependency unavailable",
+			HTTPStatus: http.StatusServiceUnavailable,
+			Err:        err,
+		}
+	case errors.As(err, &rateLimited):
+		return &response.AppError{
+			Code:       "RATE_LIMITED",
+			Message:    "dependency rate limit exceeded",
+			HTTPStatus: http.StatusTooManyRequests,
+			Err:        err,
 		}
+	case errors.Is(err, worker.ErrBusy), errors.Is(err, worker.ErrShutdown):
+		return &response.AppError{
+			Code:       "BACKPRESSURE",
+			Message:    "server busy",
+			HTTPStatus: http.StatusServiceUnavailable,
+			Err:        err,
+		}
+	case errors.Is(err, worker.ErrTimeout), errors.Is(err, context.Canceled):
+		return &response.AppError{
+			Code:       "REQUEST_TIMEOUT",
+			Message:    "request timeout",
+			HTTPStatus: http.StatusGatewayTimeout,
+			Err:        err,
+		}
+	}
+	return &response.AppError{
+		Code:       "INTERNAL_ERROR",
+		Message:    "internal error",
+		HTTPStatus: http.StatusInternalServerError,
+		Err:        err,
 	}
 }
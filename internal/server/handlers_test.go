@@ -2,10 +2,16 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/example/XXXDONGXXX/internal/config"
 	"github.com/example/XXXDONGXXX/internal/logger"
@@ -44,6 +50,7 @@ func newTestDeps(t *testing.T) Dependencies {
 			Enabled:         false,
 			IntervalMinutes: 10,
 		},
+		Jobs: config.JobsConfig{Types: []string{"example"}},
 	}
 	mgr := &config.ManagerMock{Cfg: cfg}
 	lg, err := logger.New("logs-test", "debug")
@@ -82,3 +89,129 @@ func TestPingHandler(t *testing.T) {
 		t.Fatalf("expected code OK, got %v", body["code"])
 	}
 }
+
+func TestWorkerError(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   string
+		status int
+	}{
+		{worker.ErrBusy, "BACKPRESSURE", http.StatusServiceUnavailable},
+		{fmt.Errorf("submit: %w", worker.ErrShutdown), "BACKPRESSURE", http.StatusServiceUnavailable},
+		{&worker.CircuitOpenError{Target: "x"}, "DEPENDENCY_UNAVAILABLE", http.StatusServiceUnavailable},
+		{&worker.RateLimitError{Target: "x"}, "RATE_LIMITED", http.StatusTooManyRequests},
+		{worker.ErrTimeout, "REQUEST_TIMEOUT", http.StatusGatewayTimeout},
+		{errors.New("boom"), "INTERNAL_ERROR", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		appErr := workerError(tt.err)
+		if appErr.Code != tt.code || appErr.HTTPStatus != tt.status {
+			t.Errorf("workerError(%v) = %s/%d, want %s/%d", tt.err, appErr.Code, appErr.HTTPStatus, tt.code, tt.status)
+		}
+	}
+}
+
+func TestPriorityHeaderCapped(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.Pools.MainHighInput = make(chan worker.Job, 1)
+	deps.Pools.MainLowInput = make(chan worker.Job, 1)
+	h := NewRouter(deps)
+
+	// stand-in main worker reporting the lane each job arrived on
+	lanes := make(chan string, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			var job worker.Job
+			select {
+			case job = <-deps.Pools.MainHighInput:
+				lanes <- "high"
+			case job = <-deps.Pools.MainInput:
+				lanes <- "normal"
+			case job = <-deps.Pools.MainLowInput:
+				lanes <- "low"
+			case <-stop:
+				return
+			}
+			job.Result <- worker.Result{Data: job.Input}
+		}
+	}()
+
+	for _, tt := range []struct{ header, want string }{
+		{"", "normal"},
+		{"low", "low"},
+		{"high", "normal"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", strings.NewReader(`{"message":"hi"}`))
+		if tt.header != "" {
+			req.Header.Set("X-Priority", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("X-Priority %q: expected 200, got %d: %s", tt.header, rec.Code, rec.Body)
+		}
+		if got := <-lanes; got != tt.want {
+			t.Fatalf("X-Priority %q: job queued on %s lane, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestEchoNotRateLimitedByDefaultConfig(t *testing.T) {
+	mgr, err := config.NewManager("../../config/config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext := mgr.Config().External
+	deps := newTestDeps(t)
+	limits := make(map[string]worker.LimiterConfig)
+	targets := make([]string, 0, len(ext.Targets))
+	for name, tc := range ext.Targets {
+		limits[name] = worker.LimiterConfig{
+			Rate:    tc.RatePerSec,
+			Burst:   tc.Burst,
+			MaxWait: time.Duration(tc.MaxWaitMs) * time.Millisecond,
+		}
+		targets = append(targets, name)
+	}
+	deps.Pools.Limiters = worker.NewLimiters(limits, nil)
+	deps.Pools.Breakers = worker.NewBreakers(targets, worker.BreakerConfig{
+		Window:      time.Duration(ext.Breaker.WindowSec) * time.Second,
+		MinRequests: ext.Breaker.MinRequests,
+		FailureRate: ext.Breaker.FailureRate,
+		CoolDown:    time.Duration(ext.Breaker.CoolDownSec) * time.Second,
+	}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	worker.StartMainWorkers(ctx, 4, deps.Pools, deps.Logger)
+	worker.StartDBWorkers(ctx, 4, deps.Pools, deps.Logger)
+	worker.StartExternalWorkers(ctx, 4, deps.Pools, deps.Logger)
+	t.Cleanup(func() {
+		cancel()
+		_ = deps.Pools.Shutdown(context.Background())
+	})
+	h := EchoHandler(deps)
+
+	// well past the burst and rate of every sample target
+	const n = 200
+	codes := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/echo", strings.NewReader(`{"message":"hi"}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			codes <- rec.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("echo answered %d, want every request to pass", code)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// # /api/v1/jobs 비동기 작업 API (제출, 상태 조회, 취소)
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/XXXDONGXXX/internal/response"
+	"github.com/example/XXXDONGXXX/internal/worker"
+)
+
+func jobRoutes(deps Dependencies) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Post("/", CreateJobHandler(deps))
+		r.Get("/{id}", GetJobStatusHandler(deps))
+		r.Delete("/{id}", CancelJobHandler(deps))
+	}
+}
+
+type createJobRequest struct {
+	Type  string          `json:"type"`
+	Pool  string          `json:"pool"`
+	Input json.RawMessage `json:"input"`
+}
+
+// CreateJobHandler queues a job and answers 202 with its id right away;
+// clients poll GET /api/v1/jobs/{id} for the result.
+func CreateJobHandler(deps Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !jobsEnabled(w, r, deps) {
+			return
+		}
+		var req createJobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.ErrorJSON(w, r, badRequest("invalid json", err))
+			return
+		}
+		typ, ok := worker.LookupJobType(req.Type)
+		if !ok || typ == worker.JobTypeFunc || !slices.Contains(deps.ConfigMgr.Config().Jobs.Types, req.Type) {
+			response.ErrorJSON(w, r, badRequest("unknown job type", nil))
+			return
+		}
+		pool := worker.Pool(req.Pool)
+		switch pool {
+		case "":
+			pool = worker.PoolMain
+		case worker.PoolMain, worker.PoolDB, worker.PoolExternal:
+		default:
+			response.ErrorJSON(w, r, badRequest("pool must be main, db or external", nil))
+			return
+		}
+
+		st, err := deps.Jobs.Start(r.Context(), pool, typ, req.Input)
+		if err != nil {
+			response.ErrorJSON(w, r, jobError(err))
+			return
+		}
+		w.Header().Set("Location", "/api/v1/jobs/"+st.ID)
+		response.JSON(w, r, http.StatusAccepted, "ACCEPTED", "job accepted", st)
+	}
+}
+
+func GetJobStatusHandler(deps Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !jobsEnabled(w, r, deps) {
+			return
+		}
+		st, err := deps.Jobs.Get(chi.URLParam(r, "id"))
+		if err != nil {
+			response.ErrorJSON(w, r, jobError(err))
+			return
+		}
+		response.JSON(w, r, http.StatusOK, "OK", "job", st)
+	}
+}
+
+func CancelJobHandler(deps Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !jobsEnabled(w, r, deps) {
+			return
+		}
+		st, err := deps.Jobs.Cancel(chi.URLParam(r, "id"))
+		if err != nil {
+			response.ErrorJSON(w, r, jobError(err))
+			return
+		}
+		response.JSON(w, r, http.StatusOK, "OK", "job canceled", st)
+	}
+}
+
+func jobsEnabled(w http.ResponseWriter, r *http.Request, deps Dependencies) bool {
+	if deps.Jobs != nil {
+		return true
+	}
+	response.ErrorJSON(w, r, &response.AppError{
+		Code:       "JOBS_DISABLED",
+		Message:    "async jobs are disabled",
+		HTTPStatus: http.StatusServiceUnavailable,
+	})
+	return false
+}
+
+func badRequest(msg string, err error) *response.AppError {
+	return &response.AppError{Code: "BAD_REQUEST", Message: msg, HTTPStatus: http.StatusBadRequest, Err: err}
+}
+
+func jobError(err error) *response.AppError {
+	var unknown *worker.UnknownJobTypeError
+	switch {
+	case errors.Is(err, worker.ErrJobNotFound):
+		return &response.AppError{Code: "NOT_FOUND", Message: "job not found", HTTPStatus: http.StatusNotFound, Err: err}
+	case errors.Is(err, worker.ErrJobFinished):
+		return &response.AppError{Code: "JOB_FINISHED", Message: "job already finished", HTTPStatus: http.StatusConflict, Err: err}
+	case errors.As(err, &unknown):
+		return badRequest("job type not supported on pool", err)
+	default:
+		return workerError(err)
+	}
+}
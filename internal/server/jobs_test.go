@@ -0,0 +1,104 @@
+// 비동기 작업 API 테스트
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/XXXDONGXXX/internal/worker"
+)
+
+func TestJobsAPI(t *testing.T) {
+	deps := newTestDeps(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	worker.StartMainWorkers(ctx, 1, deps.Pools, deps.Logger)
+	worker.StartDBWorkers(ctx, 1, deps.Pools, deps.Logger)
+	worker.StartExternalWorkers(ctx, 1, deps.Pools, deps.Logger)
+	t.Cleanup(func() {
+		cancel()
+		_ = deps.Pools.Shutdown(context.Background())
+	})
+	deps.Jobs = worker.NewJobStore(deps.Pools, time.Minute)
+	h := NewRouter(deps)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs",
+		strings.NewReader(`{"type":"example","input":{"message":"hi"}}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("create: expected 202, got %d: %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		Data worker.JobStatus `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	id := created.Data.ID
+	if id == "" || rec.Header().Get("Location") != "/api/v1/jobs/"+id {
+		t.Fatalf("unexpected create response: %s", rec.Body)
+	}
+
+	var got struct {
+		Data struct {
+			State  worker.JobState   `json:"state"`
+			Result map[string]string `json:"result"`
+		} `json:"data"`
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for got.Data.State != worker.JobSucceeded {
+		if time.Now().After(deadline) {
+			t.Fatalf("job state = %s, want succeeded", got.Data.State)
+		}
+		time.Sleep(5 * time.Millisecond)
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+id, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("get: expected 200, got %d", rec.Code)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+	}
+	if got.Data.Result["message"] != "hi" {
+		t.Fatalf("unexpected result: %s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/jobs/"+id, nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("cancel finished: expected 409, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("get missing: expected 404, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(`{"type":"func"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("create func job: expected 400, got %d", rec.Code)
+	}
+	// registered types still need to be listed in jobs.types
+	worker.DefaultRegistry.RegisterName(worker.PoolExternal, "jobs-unlisted", func(ctx context.Context, job worker.Job) (interface{}, error) {
+		return nil, nil
+	})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(`{"type":"jobs-unlisted","pool":"external"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("create unlisted job: expected 400, got %d", rec.Code)
+	}
+
+	if err := deps.Pools.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(`{"type":"example"}`)))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "BACKPRESSURE") {
+		t.Fatalf("create after shutdown: expected 503 BACKPRESSURE, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/example/XXXDONGXXX/internal/metrics"
 	"github.com/example/XXXDONGXXX/internal/middleware"
 	"github.com/example/XXXDONGXXX/internal/response"
+	"github.com/example/XXXDONGXXX/internal/scheduler"
 	"github.com/example/XXXDONGXXX/internal/worker"
 )
 
@@ -18,6 +19,14 @@ type Dependencies struct {
 	ConfigMgr config.Configger
 	Logger    *logger.Logger
 	Pools     *worker.Pools
+	// Scheduler is nil when scheduler.enabled is false
+	Scheduler *scheduler.Scheduler
+	// Jobs backs /api/v1/jobs; nil disables it
+	Jobs *worker.JobStore
+}
+
+type readyDetails struct {
+	Breakers []worker.BreakerStatus `json:"breakers"`
 }
 
 func NewRouter(deps Dependencies) http.Handler {
@@ -28,16 +37,29 @@ func NewRouter(deps Dependencies) http.Handler {
 	r.Use(middleware.Logging(deps.Logger))
 	r.Use(middleware.ConcurrencyLimit(deps.ConfigMgr.Config().Concurrency.MaxConcurrentRequests))
 	r.Use(middleware.Timeout(deps.ConfigMgr))
+	// clients may ask for low priority but not jump the queue with high
+	r.Use(middleware.Priority(worker.PriorityNormal, worker.PriorityNormal))
 
 	// health
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, r, http.StatusOK, "OK", "alive", nil)
 	})
 
-	// readyz - simplified stub, always ready in template
+	// readyz - always ready in template; downstream services are reported by
+	// their circuit breakers without failing the check
 	r.Get("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		// TODO: check DB/external dependencies
-		response.JSON(w, r, http.StatusOK, "READY", "ready", nil)
+		// TODO: check DB dependencies
+		var details readyDetails
+		if deps.Pools != nil && deps.Pools.Breakers != nil {
+			details.Breakers = deps.Pools.Breakers.Status()
+		}
+		msg := "ready"
+		for _, b := range details.Breakers {
+			if b.State != worker.BreakerClosed {
+				msg = "ready (degraded)"
+			}
+		}
+		response.JSON(w, r, http.StatusOK, "READY", msg, details)
 	})
 
 	r.Method(http.MethodGet, "/metrics", metrics.Handler())
@@ -45,6 +67,15 @@ func NewRouter(deps Dependencies) http.Handler {
 	// example handlers
 	r.Get("/api/v1/ping", PingHandler(deps))
 	r.Post("/api/v1/echo", EchoHandler(deps))
+	r.Route("/api/v1/jobs", jobRoutes(deps))
+
+	// admin, off unless admin.enabled and always behind the admin token
+	if admin := deps.ConfigMgr.Config().Admin; admin.Enabled {
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(middleware.BearerAuth(admin.Token))
+			r.Route("/scheduler", schedulerRoutes(deps))
+		})
+	}
 
 	return r
 }
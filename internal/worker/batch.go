@@ -0,0 +1,216 @@
+// # 배치 처리 모드 (N건 또는 T ms 단위로 모아 일괄 처리 후 작업별 결과 분배)
+package worker
+
+import (
+    "context"
+    "fmt"
+    "runtime/debug"
+    "slices"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/logger"
+    "github.com/example/XXXDONGXXX/internal/metrics"
+    "github.com/example/XXXDONGXXX/internal/txid"
+)
+
+// BatchConfig turns on batching for a pool (see Pools.Batch). Jobs whose
+// type has a BatchHandler are then gathered and handled together; batched
+// jobs bypass Pools.Breakers and Pools.Limiters.
+type BatchConfig struct {
+    // MaxItems caps a batch; values below 2 disable batching.
+    MaxItems int
+    // MaxWait is how long a worker waits for more jobs after taking the
+    // first one of a batch. Zero only batches jobs that are already queued.
+    MaxWait time.Duration
+}
+
+// BatchHandler handles several jobs of one type in one go, e.g. with a
+// multi-row insert, and returns one Result per job in the same order. An
+// error fails every job of the batch.
+type BatchHandler func(ctx context.Context, jobs []Job) ([]Result, error)
+
+// BatchHandlerFunc adapts a function over concrete input and output types
+// to a BatchHandler. Inputs are converted like in HandlerFunc; jobs whose
+// input does not fit fail on their own and are left out of the call.
+func BatchHandlerFunc[In, Out any](fn func(ctx context.Context, in []In) ([]Out, error)) BatchHandler {
+    return func(ctx context.Context, jobs []Job) ([]Result, error) {
+        results := make([]Result, len(jobs))
+        ins := make([]In, 0, len(jobs))
+        idx := make([]int, 0, len(jobs))
+        for i, job := range jobs {
+            in, err := decodeInput[In](job)
+            if err != nil {
+                results[i].Err = err
+                continue
+            }
+            ins = append(ins, in)
+            idx = append(idx, i)
+        }
+        if len(ins) == 0 {
+            return results, nil
+        }
+        outs, err := fn(ctx, ins)
+        if err != nil {
+            return nil, err
+        }
+        if len(outs) != len(ins) {
+            return nil, fmt.Errorf("worker: batch handler returned %d results for %d inputs", len(outs), len(ins))
+        }
+        for k, i := range idx {
+            results[i].Data = outs[k]
+        }
+        return results, nil
+    }
+}
+
+// handleBatch gathers more jobs of first's type until the batch holds
+// MaxItems, MaxWait has passed or a job of another type comes up, handles
+// them with h and settles those taken from the async queue. The job of
+// another type, if any, is returned for the caller to handle next, so jobs
+// still run in the order they were taken.
+func (p *Pools) handleBatch(g *group, pool Pool, h BatchHandler, first Job, lane int, turn *int, done, quit <-chan struct{}) (next Job, nextLane int, ok bool) {
+    jobs := []Job{first}
+    lanes := []int{lane}
+    timer := time.NewTimer(g.batch.MaxWait)
+    defer timer.Stop()
+    for len(jobs) < g.batch.MaxItems {
+        job, lane, taken := g.take(turn, done, quit, timer.C)
+        if !taken {
+            break
+        }
+        if job.Type != first.Type {
+            next, nextLane, ok = job, lane, true
+            break
+        }
+        jobs = append(jobs, job)
+        lanes = append(lanes, lane)
+    }
+
+    errs := handleJobs(g.ctx, g.log, p, pool, h, jobs)
+    for i, job := range jobs {
+        if lanes[i] == laneAsync {
+            g.settle(pool, job, errs[i])
+        }
+    }
+    return next, nextLane, ok
+}
+
+// handleJobs is handleJob for a batch: jobs that expired in queue are
+// dropped, the others go to h in one call and each gets its own Result.
+// It returns the error of every job.
+func handleJobs(ctx context.Context, log *logger.Logger, pools *Pools, pool Pool, h BatchHandler, jobs []Job) []error {
+    errs := make([]error, len(jobs))
+    start := time.Now()
+    live := make([]Job, 0, len(jobs))
+    idx := make([]int, 0, len(jobs))
+    for i, job := range jobs {
+        if job.TxID == "" {
+            job.TxID = txid.NewID()
+        }
+        if job.Ctx != nil && job.Ctx.Err() != nil {
+            errs[i] = expire(log, pool, job, queueWait(job, start))
+            continue
+        }
+        if job.OnStart != nil {
+            job.OnStart()
+        }
+        job.pools = pools
+        live = append(live, job)
+        idx = append(idx, i)
+    }
+    if len(live) == 0 {
+        return errs
+    }
+
+    typ := live[0].Type
+    txids := make([]string, len(live))
+    for k, job := range live {
+        txids[k] = job.TxID
+    }
+    tx := strings.Join(txids, ",")
+    log.Debugf("handling %s batch type=%s size=%d tx=%s", pool, typ, len(live), tx)
+    ctx, cancel := batchContext(ctx, live)
+    defer cancel()
+    results, err := callBatchHandler(ctx, log, pool, h, live)
+    if err == nil && len(results) != len(live) {
+        err = fmt.Errorf("worker: batch handler returned %d results for %d jobs", len(results), len(live))
+    }
+    if err != nil {
+        log.Errorf("%s batch type=%s size=%d tx=%s failed: %v", pool, typ, len(live), tx, err)
+    }
+    exec := time.Since(start)
+    metrics.IncWorkerBatch(string(pool), typ.String(), len(live))
+    for k, job := range live {
+        res := Result{Err: err}
+        if err == nil {
+            res = results[k]
+        }
+        metrics.ObserveWorkerJob(string(pool), typ.String(), res.Err == nil, queueWait(job, start), exec)
+        if job.Result != nil {
+            job.Result <- res
+        }
+        errs[idx[k]] = res.Err
+    }
+    return errs
+}
+
+// batchContext is jobContext for a batch: it has the earliest deadline of
+// the jobs' Job.Ctx, is cancelled once every one of them is (or the
+// worker's ctx ends) and carries the jobs' txid when they all share one.
+// Handlers needing the txid of each job read Job.TxID.
+func batchContext(workerCtx context.Context, jobs []Job) (context.Context, context.CancelFunc) {
+    ctx := workerCtx
+    if tx := jobs[0].TxID; !slices.ContainsFunc(jobs, func(j Job) bool { return j.TxID != tx }) {
+        ctx = txid.WithTxID(ctx, tx)
+    }
+    var deadline time.Time
+    for _, job := range jobs {
+        if job.Ctx == nil {
+            continue
+        }
+        if d, ok := job.Ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+            deadline = d
+        }
+    }
+    var cancel context.CancelFunc
+    if deadline.IsZero() {
+        ctx, cancel = context.WithCancel(ctx)
+    } else {
+        ctx, cancel = context.WithDeadline(ctx, deadline)
+    }
+
+    // a job without a Job.Ctx of its own keeps the batch alive
+    var stops []func() bool
+    if !slices.ContainsFunc(jobs, func(j Job) bool { return j.Ctx == nil }) {
+        var left atomic.Int32
+        left.Store(int32(len(jobs)))
+        for _, job := range jobs {
+            stops = append(stops, context.AfterFunc(job.Ctx, func() {
+                if left.Add(-1) == 0 {
+                    cancel()
+                }
+            }))
+        }
+    }
+    return ctx, func() {
+        for _, stop := range stops {
+            stop()
+        }
+        cancel()
+    }
+}
+
+// callBatchHandler is callHandler for a BatchHandler.
+func callBatchHandler(ctx context.Context, log *logger.Logger, pool Pool, h BatchHandler, jobs []Job) (results []Result, err error) {
+    defer func() {
+        if rec := recover(); rec != nil {
+            stack := debug.Stack()
+            log.Criticalf("panic in %s worker batch tx=%s type=%s size=%d: %v\n%s", pool, jobs[0].TxID, jobs[0].Type, len(jobs), rec, stack)
+            metrics.IncWorkerPanic(string(pool), jobs[0].Type.String())
+            results, err = nil, &PanicError{Value: rec, Stack: stack}
+        }
+    }()
+    return h(ctx, jobs)
+}
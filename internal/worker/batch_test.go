@@ -0,0 +1,143 @@
+package worker
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/logger"
+    "github.com/example/XXXDONGXXX/internal/txid"
+)
+
+func TestBatching(t *testing.T) {
+    reg := NewRegistry()
+    var mu sync.Mutex
+    var sizes []int
+    upper := reg.RegisterBatchName(PoolDB, "upper-batch", BatchHandlerFunc(func(ctx context.Context, in []string) ([]string, error) {
+        mu.Lock()
+        sizes = append(sizes, len(in))
+        mu.Unlock()
+        out := make([]string, len(in))
+        for i, s := range in {
+            out[i] = strings.ToUpper(s)
+        }
+        return out, nil
+    }))
+    lg, err := logger.New(t.TempDir(), "debug")
+    if err != nil {
+        t.Fatalf("logger: %v", err)
+    }
+    defer lg.Close()
+    pools := &Pools{
+        DBInput:  make(chan Job, 8),
+        Registry: reg,
+        Batch:    map[Pool]BatchConfig{PoolDB: {MaxItems: 4, MaxWait: 20 * time.Millisecond}},
+    }
+
+    // queued before the worker starts: a job of another type in the middle
+    // ends the batch and is handled on its own, a bad input fails alone
+    inputs := []interface{}{"a", "b", 42, "c", "d", "e"}
+    results := make([]chan Result, len(inputs))
+    for i, in := range inputs {
+        results[i] = make(chan Result, 1)
+        typ := upper
+        if i == 2 {
+            typ = JobTypeExample
+        }
+        pools.DBInput <- Job{Type: typ, Input: in, Result: results[i]}
+    }
+    bad := make(chan Result, 1)
+    StartDBWorkers(context.Background(), 1, pools, lg)
+    defer pools.Shutdown(context.Background())
+    pools.DBInput <- Job{Type: upper, Input: 7, Result: bad}
+
+    for i, want := range []interface{}{"A", "B", 42, "C", "D", "E"} {
+        if res := <-results[i]; res.Err != nil || res.Data != want {
+            t.Fatalf("job %d = %v, %v; want %v", i, res.Data, res.Err, want)
+        }
+    }
+    if res := <-bad; res.Err == nil {
+        t.Fatal("want error for bad input")
+    }
+    mu.Lock()
+    defer mu.Unlock()
+    if len(sizes) != 2 || sizes[0] != 2 || sizes[1] != 3 {
+        t.Fatalf("batch sizes = %v, want [2 3]", sizes)
+    }
+}
+
+func TestBatchHandlerError(t *testing.T) {
+    reg := NewRegistry()
+    fail := reg.RegisterBatchName(PoolDB, "fail-batch", func(ctx context.Context, jobs []Job) ([]Result, error) {
+        return nil, errors.New("insert failed")
+    })
+    lg, err := logger.New(t.TempDir(), "debug")
+    if err != nil {
+        t.Fatalf("logger: %v", err)
+    }
+    defer lg.Close()
+    pools := &Pools{
+        DBInput:  make(chan Job, 2),
+        Registry: reg,
+        Batch:    map[Pool]BatchConfig{PoolDB: {MaxItems: 2}},
+    }
+    res := make(chan Result, 2)
+    pools.DBInput <- Job{Type: fail, Result: res}
+    pools.DBInput <- Job{Type: fail, Result: res}
+    StartDBWorkers(context.Background(), 1, pools, lg)
+    defer pools.Shutdown(context.Background())
+
+    for i := 0; i < 2; i++ {
+        if r := <-res; r.Err == nil || r.Err.Error() != "insert failed" {
+            t.Fatalf("job %d err = %v", i, r.Err)
+        }
+    }
+
+    // without batching the batch handler still serves single jobs
+    if r := send(startTestPools(t, reg), PoolDB, fail, nil); r.Err == nil {
+        t.Fatal("want error from single-job fallback")
+    }
+}
+
+func TestBatchContext(t *testing.T) {
+    near := time.Now().Add(time.Hour)
+    ctx1, cancel1 := context.WithDeadline(context.Background(), near.Add(time.Hour))
+    defer cancel1()
+    ctx2, cancel2 := context.WithDeadline(context.Background(), near)
+    defer cancel2()
+
+    ctx, cancel := batchContext(context.Background(), []Job{{TxID: "tx", Ctx: ctx1}, {TxID: "tx", Ctx: ctx2}})
+    defer cancel()
+    if d, ok := ctx.Deadline(); !ok || !d.Equal(near) {
+        t.Fatalf("batch deadline = %v, %v; want the earliest job deadline", d, ok)
+    }
+    if tx := txid.FromContext(ctx); tx != "tx" {
+        t.Fatalf("batch txid = %q", tx)
+    }
+    cancel1()
+    time.Sleep(10 * time.Millisecond)
+    if ctx.Err() != nil {
+        t.Fatal("batch cancelled while a job is still live")
+    }
+    cancel2()
+    select {
+    case <-ctx.Done():
+    case <-time.After(time.Second):
+        t.Fatal("batch not cancelled once every job was")
+    }
+
+    ctx3, cancel3 := context.WithCancel(context.Background())
+    cancel3()
+    ctx, cancel = batchContext(context.Background(), []Job{{TxID: "a", Ctx: ctx3}, {TxID: "b"}})
+    defer cancel()
+    time.Sleep(10 * time.Millisecond)
+    if ctx.Err() != nil {
+        t.Fatal("batch cancelled although a job has no ctx of its own")
+    }
+    if tx := txid.FromContext(ctx); tx != "" {
+        t.Fatalf("batch of mixed txids carries %q", tx)
+    }
+}
@@ -0,0 +1,276 @@
+// # 외부 대상별 서킷 브레이커 (closed/open/half-open, 실패율 윈도우, 쿨다운)
+package worker
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/clock"
+    "github.com/example/XXXDONGXXX/internal/metrics"
+)
+
+type BreakerState int
+
+const (
+    BreakerClosed BreakerState = iota
+    BreakerOpen
+    BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+    switch s {
+    case BreakerOpen:
+        return "open"
+    case BreakerHalfOpen:
+        return "half-open"
+    default:
+        return "closed"
+    }
+}
+
+func (s BreakerState) MarshalText() ([]byte, error) {
+    return []byte(s.String()), nil
+}
+
+// CircuitOpenError is the Result.Err of jobs for a Target whose breaker is
+// open; they fail without running.
+type CircuitOpenError struct {
+    Target string
+}
+
+func (e *CircuitOpenError) Error() string {
+    return fmt.Sprintf("worker: circuit open for %s", e.Target)
+}
+
+// BreakerConfig tunes a Breaker. Zero values pick the defaults.
+type BreakerConfig struct {
+    // Window is the span over which the failure rate is measured, default
+    // 30 seconds.
+    Window time.Duration
+    // MinRequests is the number of calls in the window below which the
+    // breaker never opens, default 10.
+    MinRequests int
+    // FailureRate in (0, 1] opens the breaker, default 0.5.
+    FailureRate float64
+    // CoolDown is how long the breaker stays open before letting probe calls
+    // through, default 30 seconds.
+    CoolDown time.Duration
+    // HalfOpenProbes successful probes close the breaker again, default 1.
+    HalfOpenProbes int
+}
+
+func (c BreakerConfig) withDefaults() BreakerConfig {
+    if c.Window <= 0 {
+        c.Window = 30 * time.Second
+    }
+    if c.MinRequests <= 0 {
+        c.MinRequests = 10
+    }
+    if c.FailureRate <= 0 || c.FailureRate > 1 {
+        c.FailureRate = 0.5
+    }
+    if c.CoolDown <= 0 {
+        c.CoolDown = 30 * time.Second
+    }
+    if c.HalfOpenProbes <= 0 {
+        c.HalfOpenProbes = 1
+    }
+    return c
+}
+
+// the failure-rate window is kept as this many buckets
+const breakerBuckets = 10
+
+type breakerBucket struct {
+    start         time.Time
+    total, failed int
+}
+
+// Breaker is the circuit breaker of one downstream target. It opens when
+// the failure rate over the window reaches FailureRate, rejects calls for
+// CoolDown, then lets HalfOpenProbes calls through: one failure reopens it,
+// all succeeding close it.
+type Breaker struct {
+    target string
+    cfg    BreakerConfig
+    clock  clock.Clock
+
+    mu        sync.Mutex
+    state     BreakerState
+    openedAt  time.Time
+    buckets   [breakerBuckets]breakerBucket
+    probes    int
+    succeeded int
+}
+
+func NewBreaker(target string, cfg BreakerConfig, clk clock.Clock) *Breaker {
+    if clk == nil {
+        clk = clock.Real{}
+    }
+    return &Breaker{target: target, cfg: cfg.withDefaults(), clock: clk}
+}
+
+// Allow reports whether a call may go ahead, returning a *CircuitOpenError
+// when not. Every allowed call must be followed by Record.
+func (b *Breaker) Allow() error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.coolLocked()
+    switch {
+    case b.state == BreakerClosed:
+        return nil
+    case b.state == BreakerHalfOpen && b.probes < b.cfg.HalfOpenProbes:
+        b.probes++
+        return nil
+    }
+    metrics.IncBreakerRejected(b.target)
+    return &CircuitOpenError{Target: b.target}
+}
+
+// Rejecting reports whether Allow would fail right now, without taking a
+// half-open probe.
+func (b *Breaker) Rejecting() bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.coolLocked()
+    return b.state == BreakerOpen || b.state == BreakerHalfOpen && b.probes >= b.cfg.HalfOpenProbes
+}
+
+// Record reports the outcome of an allowed call.
+func (b *Breaker) Record(failed bool) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    now := b.clock.Now()
+    switch b.state {
+    case BreakerHalfOpen:
+        if failed {
+            b.setLocked(BreakerOpen, now)
+            return
+        }
+        b.succeeded++
+        if b.succeeded >= b.cfg.HalfOpenProbes {
+            b.setLocked(BreakerClosed, now)
+        }
+    case BreakerClosed:
+        bk := b.bucketLocked(now)
+        bk.total++
+        if failed {
+            bk.failed++
+        }
+        total, fails := b.countLocked(now)
+        if failed && total >= b.cfg.MinRequests && float64(fails) >= b.cfg.FailureRate*float64(total) {
+            b.setLocked(BreakerOpen, now)
+        }
+    }
+}
+
+// coolLocked moves an open breaker to half-open once CoolDown has passed.
+func (b *Breaker) coolLocked() {
+    now := b.clock.Now()
+    if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.cfg.CoolDown {
+        b.setLocked(BreakerHalfOpen, now)
+    }
+}
+
+func (b *Breaker) setLocked(s BreakerState, now time.Time) {
+    if s == b.state {
+        return
+    }
+    b.state = s
+    b.probes, b.succeeded = 0, 0
+    switch s {
+    case BreakerOpen:
+        b.openedAt = now
+    case BreakerClosed:
+        b.buckets = [breakerBuckets]breakerBucket{}
+    }
+    metrics.IncBreakerTransition(b.target, s.String())
+}
+
+func (b *Breaker) bucketWidth() time.Duration {
+    return b.cfg.Window / breakerBuckets
+}
+
+func (b *Breaker) bucketLocked(now time.Time) *breakerBucket {
+    start := now.Truncate(b.bucketWidth())
+    bk := &b.buckets[start.UnixNano()/int64(b.bucketWidth())%breakerBuckets]
+    if !bk.start.Equal(start) {
+        *bk = breakerBucket{start: start}
+    }
+    return bk
+}
+
+func (b *Breaker) countLocked(now time.Time) (total, failed int) {
+    since := now.Add(-b.cfg.Window)
+    for _, bk := range b.buckets {
+        if bk.start.After(since) {
+            total += bk.total
+            failed += bk.failed
+        }
+    }
+    return total, failed
+}
+
+// BreakerStatus is a snapshot of one Breaker.
+type BreakerStatus struct {
+    Target   string       `json:"target"`
+    State    BreakerState `json:"state"`
+    Requests int          `json:"requests"`
+    Failures int          `json:"failures"`
+}
+
+func (b *Breaker) Status() BreakerStatus {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.coolLocked()
+    total, failed := b.countLocked(b.clock.Now())
+    return BreakerStatus{Target: b.target, State: b.state, Requests: total, Failures: failed}
+}
+
+// Breakers holds one Breaker per configured target. Targets are fixed up
+// front so that job types or Job.Target values cannot grow it without bound.
+type Breakers struct {
+    m map[string]*Breaker
+}
+
+func NewBreakers(targets []string, cfg BreakerConfig, clk clock.Clock) *Breakers {
+    bs := &Breakers{m: make(map[string]*Breaker, len(targets))}
+    for _, name := range targets {
+        bs.m[name] = NewBreaker(name, cfg, clk)
+    }
+    return bs
+}
+
+// Get returns target's breaker, or nil when target has none.
+func (bs *Breakers) Get(target string) *Breaker {
+    return bs.m[target]
+}
+
+// Status returns every breaker's status sorted by target.
+func (bs *Breakers) Status() []BreakerStatus {
+    out := make([]BreakerStatus, 0, len(bs.m))
+    for _, b := range bs.m {
+        out = append(out, b.Status())
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+    return out
+}
+
+// Stats feeds metrics.SetBreakerStats.
+func (bs *Breakers) Stats() []metrics.BreakerStats {
+    var stats []metrics.BreakerStats
+    for _, st := range bs.Status() {
+        stats = append(stats, metrics.BreakerStats{Target: st.Target, State: int(st.State)})
+    }
+    return stats
+}
+
+// breakerFailure reports whether err counts against a breaker: a caller
+// cancelling its own request says nothing about the downstream.
+func breakerFailure(err error) bool {
+    return err != nil && !errors.Is(err, context.Canceled)
+}
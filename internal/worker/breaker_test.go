@@ -0,0 +1,121 @@
+package worker
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/clock"
+)
+
+func TestBreaker(t *testing.T) {
+    fc := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+    b := NewBreaker("partner", BreakerConfig{Window: 10 * time.Second, MinRequests: 4, FailureRate: 0.5, CoolDown: 5 * time.Second}, fc)
+
+    for _, failed := range []bool{true, false, true} {
+        if err := b.Allow(); err != nil {
+            t.Fatal(err)
+        }
+        b.Record(failed)
+    }
+    if st := b.Status(); st.State != BreakerClosed || st.Requests != 3 || st.Failures != 2 {
+        t.Fatalf("below MinRequests: %+v", st)
+    }
+    // failures older than the window no longer count
+    fc.Advance(11 * time.Second)
+    b.Record(true)
+    if st := b.Status(); st.State != BreakerClosed || st.Requests != 1 {
+        t.Fatalf("after window: %+v", st)
+    }
+    b.Record(false)
+    b.Record(true)
+    b.Record(true)
+    var open *CircuitOpenError
+    if err := b.Allow(); !errors.As(err, &open) || open.Target != "partner" {
+        t.Fatalf("Allow on open breaker = %v", err)
+    }
+
+    fc.Advance(5 * time.Second)
+    if err := b.Allow(); err != nil {
+        t.Fatalf("probe after cool-down: %v", err)
+    }
+    if err := b.Allow(); err == nil {
+        t.Fatal("second probe allowed")
+    }
+    b.Record(true)
+    if st := b.Status(); st.State != BreakerOpen {
+        t.Fatalf("failed probe: state %s", st.State)
+    }
+
+    fc.Advance(5 * time.Second)
+    if err := b.Allow(); err != nil {
+        t.Fatal(err)
+    }
+    b.Record(false)
+    if st := b.Status(); st.State != BreakerClosed || st.Requests != 0 {
+        t.Fatalf("successful probe: %+v", st)
+    }
+}
+
+func TestBreakerFailsJobsFast(t *testing.T) {
+    reg := NewRegistry()
+    var calls int
+    partner := reg.RegisterName(PoolExternal, "partner", func(ctx context.Context, job Job) (interface{}, error) {
+        calls++
+        return nil, errors.New("502 from partner")
+    })
+    pools := startTestPools(t, reg)
+    pools.Breakers = NewBreakers([]string{"partner"}, BreakerConfig{MinRequests: 2, CoolDown: time.Hour}, nil)
+
+    for i := 0; i < 2; i++ {
+        if res := send(pools, PoolExternal, partner, nil); res.Err == nil {
+            t.Fatal("want handler error")
+        }
+    }
+    var open *CircuitOpenError
+    if _, err := Submit[any, any](context.Background(), pools, PoolExternal, partner, nil); !errors.As(err, &open) {
+        t.Fatalf("Submit with open breaker err = %v", err)
+    }
+    if res := send(pools, PoolExternal, partner, nil); !errors.As(res.Err, &open) {
+        t.Fatalf("queued job with open breaker err = %v", res.Err)
+    }
+    if calls != 2 {
+        t.Fatalf("handler called %d times, want 2", calls)
+    }
+    if st := pools.Breakers.Status(); len(st) != 1 || st[0].Target != "partner" || st[0].State != BreakerOpen {
+        t.Fatalf("breakers = %+v", st)
+    }
+}
+
+func TestBreakersConfiguredTargetsOnly(t *testing.T) {
+    reg := NewRegistry()
+    flaky := reg.RegisterName(PoolExternal, "flaky", func(ctx context.Context, job Job) (interface{}, error) {
+        return nil, errors.New("502")
+    })
+    // registered on main only, so the external pool has no handler for it
+    orphan := reg.RegisterName(PoolMain, "orphan", func(ctx context.Context, job Job) (interface{}, error) {
+        return nil, nil
+    })
+    pools := startTestPools(t, reg)
+    pools.Breakers = NewBreakers([]string{"partner"}, BreakerConfig{MinRequests: 1, CoolDown: time.Hour}, nil)
+
+    for i := 0; i < 3; i++ {
+        if res := send(pools, PoolExternal, flaky, nil); res.Err == nil || errors.As(res.Err, new(*CircuitOpenError)) {
+            t.Fatalf("unconfigured target err = %v, want handler error", res.Err)
+        }
+    }
+    if pools.Breakers.Get("flaky") != nil {
+        t.Fatal("breaker created for unconfigured target")
+    }
+
+    res := make(chan Result, 1)
+    pools.ExtInput <- Job{Type: orphan, Target: "partner", Result: res}
+    var unknown *UnknownJobTypeError
+    if r := <-res; !errors.As(r.Err, &unknown) {
+        t.Fatalf("orphan job err = %v", r.Err)
+    }
+    if st := pools.Breakers.Status(); len(st) != 1 || st[0].State != BreakerClosed || st[0].Requests != 0 {
+        t.Fatalf("breakers after missing handler = %+v", st)
+    }
+}
@@ -0,0 +1,115 @@
+// # 하위 작업 분기/취합 (main -> db/external, ctx 취소 공유, txid 전파)
+package worker
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/txid"
+)
+
+// Fanout sends sub-jobs of a job being handled to other pools and gathers
+// their results. Sub-jobs carry the parent's txid and priority and share one
+// context, which is cancelled when the parent's ctx or Job.Ctx ends or as
+// soon as one sub-job fails.
+//
+// Sub-jobs should go to a different pool than the parent: a handler waiting
+// on its own pool can deadlock it once every worker does the same.
+type Fanout struct {
+    pools  *Pools
+    parent Job
+    ctx    context.Context
+    cancel context.CancelFunc
+    stop   func() bool
+    wg     sync.WaitGroup
+
+    mu      sync.Mutex
+    results []interface{}
+    err     error
+}
+
+// NewFanout starts a fan-out for job, which must be the Job passed to the
+// calling handler.
+func NewFanout(ctx context.Context, job Job) *Fanout {
+    if txid.FromContext(ctx) == "" && job.TxID != "" {
+        ctx = txid.WithTxID(ctx, job.TxID)
+    }
+    ctx, cancel := context.WithCancel(ctx)
+    f := &Fanout{pools: job.pools, parent: job, ctx: ctx, cancel: cancel, stop: func() bool { return false }}
+    if job.Ctx != nil {
+        f.stop = context.AfterFunc(job.Ctx, cancel)
+    }
+    return f
+}
+
+// Go queues a sub-job of type typ on pool, waiting for room if the queue is
+// full. Its result ends up at the same index in Wait's results as the order
+// of the Go call.
+func (f *Fanout) Go(pool Pool, typ JobType, in interface{}) {
+    f.mu.Lock()
+    i := len(f.results)
+    f.results = append(f.results, nil)
+    f.mu.Unlock()
+
+    if f.pools == nil {
+        f.fail(fmt.Errorf("worker: fan-out from a job not run by a worker"))
+        return
+    }
+    res := make(chan Result, 1)
+    job := Job{
+        Type:       typ,
+        TxID:       f.parent.TxID,
+        Ctx:        f.ctx,
+        Input:      in,
+        Result:     res,
+        EnqueuedAt: time.Now(),
+        Priority:   f.parent.Priority,
+    }
+    if err := f.pools.Enqueue(f.ctx, pool, job); err != nil {
+        f.fail(fmt.Errorf("worker: %s sub-job %s: %w", pool, typ, err))
+        return
+    }
+
+    f.wg.Add(1)
+    go func() {
+        defer f.wg.Done()
+        select {
+        case r := <-res:
+            if r.Err != nil {
+                f.fail(fmt.Errorf("worker: %s sub-job %s: %w", pool, typ, r.Err))
+                return
+            }
+            f.mu.Lock()
+            f.results[i] = r.Data
+            f.mu.Unlock()
+        case <-f.ctx.Done():
+            f.fail(ctxErr(f.ctx))
+        }
+    }()
+}
+
+// fail keeps the first error and cancels the remaining sub-jobs.
+func (f *Fanout) fail(err error) {
+    f.mu.Lock()
+    if f.err == nil {
+        f.err = err
+    }
+    f.mu.Unlock()
+    f.cancel()
+}
+
+// Wait returns the sub-job results in the order they were started, or the
+// first error. It does not wait for cancelled sub-jobs still in a queue.
+func (f *Fanout) Wait() ([]interface{}, error) {
+    f.wg.Wait()
+    f.stop()
+    f.cancel()
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if f.err != nil {
+        return nil, f.err
+    }
+    return f.results, nil
+}
@@ -0,0 +1,95 @@
+package worker
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/txid"
+)
+
+func TestFanout(t *testing.T) {
+    reg := NewRegistry()
+    upper := reg.RegisterName(PoolDB, "upper", HandlerFunc(func(ctx context.Context, s string) (string, error) {
+        return strings.ToUpper(s), nil
+    }))
+    tx := reg.RegisterName(PoolExternal, "tx", func(ctx context.Context, job Job) (interface{}, error) {
+        return job.TxID + "/" + txid.FromContext(job.Ctx), nil
+    })
+    fail := reg.RegisterName(PoolDB, "fail", func(ctx context.Context, job Job) (interface{}, error) {
+        return nil, errors.New("boom")
+    })
+    cancelled := make(chan error, 1)
+    wait := reg.RegisterName(PoolExternal, "wait", func(ctx context.Context, job Job) (interface{}, error) {
+        <-job.Ctx.Done()
+        cancelled <- job.Ctx.Err()
+        return nil, job.Ctx.Err()
+    })
+    reg.RegisterName(PoolMain, "pipeline", func(ctx context.Context, job Job) (interface{}, error) {
+        f := NewFanout(ctx, job)
+        for _, typ := range job.Input.([]JobType) {
+            pool := PoolDB
+            if typ == tx || typ == wait {
+                pool = PoolExternal
+            }
+            f.Go(pool, typ, "abc")
+        }
+        return f.Wait()
+    })
+    pools := startTestPools(t, reg)
+    pipeline, _ := LookupJobType("pipeline")
+
+    res := send(pools, PoolMain, pipeline, []JobType{upper, tx})
+    out, _ := res.Data.([]interface{})
+    if res.Err != nil || len(out) != 2 || out[0] != "ABC" || out[1] != "tx/tx" {
+        t.Fatalf("fan-out = %v, %v", res.Data, res.Err)
+    }
+
+    res = send(pools, PoolMain, pipeline, []JobType{wait, fail})
+    if res.Err == nil || !strings.Contains(res.Err.Error(), "boom") {
+        t.Fatalf("fan-out with failing sub-job err = %v", res.Err)
+    }
+    if err := <-cancelled; !errors.Is(err, context.Canceled) {
+        t.Fatalf("sibling sub-job ctx err = %v", err)
+    }
+}
+
+func TestFanoutDuringShutdown(t *testing.T) {
+    reg := NewRegistry()
+    upper := reg.RegisterName(PoolDB, "upper", HandlerFunc(func(ctx context.Context, s string) (string, error) {
+        return strings.ToUpper(s), nil
+    }))
+    started := make(chan struct{})
+    release := make(chan struct{})
+    pipeline := reg.RegisterName(PoolMain, "pipeline", func(ctx context.Context, job Job) (interface{}, error) {
+        close(started)
+        <-release
+        f := NewFanout(ctx, job)
+        f.Go(PoolDB, upper, "abc")
+        return f.Wait()
+    })
+    pools := startTestPools(t, reg)
+
+    res := make(chan Result, 1)
+    pools.MainInput <- Job{Type: pipeline, Result: res}
+    <-started
+    shut := make(chan error, 1)
+    go func() { shut <- pools.Shutdown(context.Background()) }()
+    // the main pool is stopping; its handler fans out only now
+    time.Sleep(20 * time.Millisecond)
+    close(release)
+
+    r := <-res
+    out, _ := r.Data.([]interface{})
+    if r.Err != nil || len(out) != 1 || out[0] != "ABC" {
+        t.Fatalf("fan-out during shutdown = %v, %v", r.Data, r.Err)
+    }
+    if err := <-shut; err != nil {
+        t.Fatalf("Shutdown: %v", err)
+    }
+    if _, err := Submit[string, string](context.Background(), pools, PoolDB, upper, "x"); !errors.Is(err, ErrShutdown) {
+        t.Fatalf("db pool after Shutdown err = %v, want ErrShutdown", err)
+    }
+}
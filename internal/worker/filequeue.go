@@ -0,0 +1,628 @@
+// # 파일 기반 영속 작업 큐 (WAL, ack/가시성 타임아웃/재전달/DLQ)
+package worker
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "slices"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/txid"
+)
+
+// FileQueueOptions tunes a FileQueue. Zero values pick the defaults.
+type FileQueueOptions struct {
+    // VisibilityTimeout is how long a delivered job may stay unsettled
+    // before it is delivered again, default 5 minutes.
+    VisibilityTimeout time.Duration
+    // MaxAttempts is the number of deliveries a job gets before it is moved
+    // to the dead-letter file, default 5.
+    MaxAttempts int
+    // MaxLen bounds the number of stored jobs; 0 means unbounded.
+    MaxLen int
+    // RetryDelay is how long a failed job waits before it is delivered
+    // again, default 1 second. It doubles with every further attempt up to
+    // MaxRetryDelay, default 1 minute.
+    RetryDelay    time.Duration
+    MaxRetryDelay time.Duration
+    // OnError receives write errors of the WAL and the dead-letter file
+    // that have no caller to return to. It must not call back into the
+    // queue.
+    OnError func(error)
+}
+
+// DeadLetter is a job that used up its attempts.
+type DeadLetter struct {
+    ID       string          `json:"id"`
+    Type     string          `json:"type"`
+    TxID     string          `json:"txId"`
+    Input    json.RawMessage `json:"input"`
+    Attempts int             `json:"attempts"`
+    Error    string          `json:"error"`
+    At       time.Time       `json:"at"`
+}
+
+type fileJob struct {
+    ID         string          `json:"id"`
+    Type       string          `json:"type"`
+    TxID       string          `json:"txId,omitempty"`
+    Input      json.RawMessage `json:"input"`
+    EnqueuedAt time.Time       `json:"enqueuedAt"`
+    Attempts   int             `json:"attempts"`
+    // NotBefore delays the redelivery of a failed job
+    NotBefore time.Time `json:"notBefore,omitzero"`
+}
+
+// WAL operations
+const (
+    opPut  = "put"
+    opTake = "take"
+    opNack = "nack"
+    opDone = "done"
+    opDead = "dead"
+)
+
+type walRecord struct {
+    Op  string   `json:"op"`
+    ID  string   `json:"id"`
+    Job *fileJob `json:"job,omitempty"`
+    // Until is the NotBefore set by a nack
+    Until time.Time `json:"until,omitzero"`
+}
+
+// the WAL is rewritten once it holds this many more records than live jobs
+const compactSlack = 1024
+
+// FileQueue is a durable Queue kept in an append-only log (queue.wal) under
+// its directory. Delivery is at least once: a job is removed only when it is
+// settled without error, jobs that were in flight during a crash or stayed
+// unsettled past the visibility timeout are delivered again, and jobs out of
+// attempts are appended to dead.jsonl. Failed jobs are retried after a delay
+// that grows with their attempt count.
+type FileQueue struct {
+    dir  string
+    opts FileQueueOptions
+    out  chan Job
+    wake chan struct{}
+    quit chan struct{}
+    wg   sync.WaitGroup
+
+    mu       sync.Mutex
+    wal      *os.File
+    dead     *os.File
+    jobs     map[string]*fileJob
+    ready    []string
+    inflight map[string]time.Time
+    // failed jobs waiting for their NotBefore
+    delayed map[string]time.Time
+    records int
+    // closed and replaced whenever a job leaves, for Put waiting on MaxLen
+    space  chan struct{}
+    closed bool
+}
+
+// NewFileQueue opens or creates the queue stored in dir. Jobs left over from
+// a previous run, including those that were in flight, are queued again.
+func NewFileQueue(dir string, opts FileQueueOptions) (*FileQueue, error) {
+    if opts.VisibilityTimeout <= 0 {
+        opts.VisibilityTimeout = 5 * time.Minute
+    }
+    if opts.MaxAttempts <= 0 {
+        opts.MaxAttempts = 5
+    }
+    if opts.RetryDelay <= 0 {
+        opts.RetryDelay = time.Second
+    }
+    if opts.MaxRetryDelay <= 0 {
+        opts.MaxRetryDelay = time.Minute
+    }
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return nil, fmt.Errorf("worker: create queue dir: %w", err)
+    }
+    q := &FileQueue{
+        dir:      dir,
+        opts:     opts,
+        out:      make(chan Job),
+        wake:     make(chan struct{}, 1),
+        quit:     make(chan struct{}),
+        jobs:     make(map[string]*fileJob),
+        inflight: make(map[string]time.Time),
+        delayed:  make(map[string]time.Time),
+        space:    make(chan struct{}),
+    }
+    if err := q.load(); err != nil {
+        return nil, err
+    }
+    if err := q.compactLocked(); err != nil {
+        return nil, err
+    }
+    dead, err := os.OpenFile(filepath.Join(dir, "dead.jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+    if err != nil {
+        q.wal.Close()
+        return nil, fmt.Errorf("worker: open dead-letter file: %w", err)
+    }
+    q.dead = dead
+
+    q.wg.Add(1)
+    go q.pump()
+    return q, nil
+}
+
+func (q *FileQueue) walPath() string {
+    return filepath.Join(q.dir, "queue.wal")
+}
+
+func (q *FileQueue) load() error {
+    f, err := os.Open(q.walPath())
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("worker: open queue wal: %w", err)
+    }
+    defer f.Close()
+
+    var order []string
+    sc := bufio.NewScanner(f)
+    sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+    for sc.Scan() {
+        var rec walRecord
+        if json.Unmarshal(sc.Bytes(), &rec) != nil {
+            continue // torn write
+        }
+        switch rec.Op {
+        case opPut:
+            if rec.Job != nil {
+                q.jobs[rec.ID] = rec.Job
+                order = append(order, rec.ID)
+            }
+        case opTake:
+            if j, ok := q.jobs[rec.ID]; ok {
+                j.Attempts++
+            }
+        case opNack:
+            if j, ok := q.jobs[rec.ID]; ok {
+                j.NotBefore = rec.Until
+            }
+        case opDone, opDead:
+            delete(q.jobs, rec.ID)
+        }
+    }
+    if err := sc.Err(); err != nil {
+        return fmt.Errorf("worker: read queue wal: %w", err)
+    }
+    now := time.Now()
+    for _, id := range order {
+        j, ok := q.jobs[id]
+        switch {
+        case !ok, slices.Contains(q.ready, id):
+        case j.NotBefore.After(now):
+            q.delayed[id] = j.NotBefore
+        default:
+            q.ready = append(q.ready, id)
+        }
+    }
+    return nil
+}
+
+// compactLocked rewrites the WAL with one put record per stored job, ready
+// jobs first in delivery order. Caller holds q.mu or owns q exclusively.
+func (q *FileQueue) compactLocked() error {
+    ids := slices.Clone(q.ready)
+    for id := range q.inflight {
+        ids = append(ids, id)
+    }
+    for id := range q.delayed {
+        ids = append(ids, id)
+    }
+
+    tmp := q.walPath() + ".tmp"
+    f, err := os.Create(tmp)
+    if err != nil {
+        return fmt.Errorf("worker: compact queue wal: %w", err)
+    }
+    w := bufio.NewWriter(f)
+    for _, id := range ids {
+        b, err := json.Marshal(walRecord{Op: opPut, ID: id, Job: q.jobs[id]})
+        if err != nil {
+            f.Close()
+            return err
+        }
+        w.Write(b)
+        w.WriteByte('\n')
+    }
+    if err := w.Flush(); err != nil {
+        f.Close()
+        return fmt.Errorf("worker: compact queue wal: %w", err)
+    }
+    if err := f.Sync(); err != nil {
+        f.Close()
+        return fmt.Errorf("worker: compact queue wal: %w", err)
+    }
+    f.Close()
+    if err := os.Rename(tmp, q.walPath()); err != nil {
+        return fmt.Errorf("worker: compact queue wal: %w", err)
+    }
+
+    wal, err := os.OpenFile(q.walPath(), os.O_APPEND|os.O_WRONLY, 0o644)
+    if err != nil {
+        return fmt.Errorf("worker: open queue wal: %w", err)
+    }
+    if q.wal != nil {
+        q.wal.Close()
+    }
+    q.wal = wal
+    q.records = len(ids)
+    return nil
+}
+
+// appendLocked writes rec to the WAL, syncing when durable is set. Caller
+// holds q.mu.
+func (q *FileQueue) appendLocked(rec walRecord, durable bool) error {
+    b, err := json.Marshal(rec)
+    if err != nil {
+        return err
+    }
+    if _, err := q.wal.Write(append(b, '\n')); err != nil {
+        return fmt.Errorf("worker: write queue wal: %w", err)
+    }
+    if durable {
+        if err := q.wal.Sync(); err != nil {
+            return fmt.Errorf("worker: sync queue wal: %w", err)
+        }
+    }
+    q.records++
+    return nil
+}
+
+// Put stores job and returns once it is on disk.
+func (q *FileQueue) Put(ctx context.Context, job Job) error {
+    input, err := json.Marshal(job.Input)
+    if err != nil {
+        return fmt.Errorf("worker: encode job input: %w", err)
+    }
+    fj := &fileJob{
+        ID:         job.ID,
+        Type:       job.Type.String(),
+        TxID:       job.TxID,
+        Input:      input,
+        EnqueuedAt: job.EnqueuedAt,
+    }
+    if fj.ID == "" {
+        fj.ID = txid.NewID()
+    }
+    if fj.EnqueuedAt.IsZero() {
+        fj.EnqueuedAt = time.Now()
+    }
+
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    for !q.closed && q.opts.MaxLen > 0 && len(q.jobs) >= q.opts.MaxLen {
+        space := q.space
+        q.mu.Unlock()
+        select {
+        case <-space:
+        case <-ctx.Done():
+            q.mu.Lock()
+            return ctxErr(ctx)
+        }
+        q.mu.Lock()
+    }
+    if q.closed {
+        return ErrShutdown
+    }
+    if _, dup := q.jobs[fj.ID]; dup {
+        return fmt.Errorf("worker: job %s already queued", fj.ID)
+    }
+    if err := q.appendLocked(walRecord{Op: opPut, ID: fj.ID, Job: fj}, true); err != nil {
+        return err
+    }
+    q.jobs[fj.ID] = fj
+    q.ready = append(q.ready, fj.ID)
+    q.signal()
+    return nil
+}
+
+func (q *FileQueue) C() <-chan Job {
+    return q.out
+}
+
+// Settle acknowledges a delivered job when err is nil. Otherwise the job is
+// delivered again after its retry delay, or dead-lettered once it is out of
+// attempts. A non-nil return means the outcome could not be written; the
+// job is then delivered again, at the latest after a restart.
+func (q *FileQueue) Settle(job Job, err error) error {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    j, ok := q.jobs[job.ID]
+    if !ok || q.closed {
+        return nil
+    }
+    delete(q.inflight, job.ID)
+    var werr error
+    switch {
+    case err == nil:
+        q.removeLocked(j.ID)
+        werr = q.appendLocked(walRecord{Op: opDone, ID: j.ID}, false)
+    case j.Attempts >= q.opts.MaxAttempts:
+        werr = q.deadLocked(j, err.Error())
+    default:
+        werr = q.delayLocked(j)
+    }
+    if werr != nil {
+        werr = fmt.Errorf("worker: settle job %s: %w", j.ID, werr)
+    }
+    if q.records > 2*len(q.jobs)+compactSlack {
+        werr = errors.Join(werr, q.compactLocked())
+    }
+    return werr
+}
+
+// retryDelay is the wait before attempt+1: RetryDelay doubled for every
+// attempt after the first, capped at MaxRetryDelay.
+func (q *FileQueue) retryDelay(attempt int) time.Duration {
+    d := q.opts.RetryDelay
+    for i := 1; i < attempt && d < q.opts.MaxRetryDelay; i++ {
+        d *= 2
+    }
+    return min(d, q.opts.MaxRetryDelay)
+}
+
+// delayLocked schedules the redelivery of a failed job. Caller holds q.mu.
+func (q *FileQueue) delayLocked(j *fileJob) error {
+    if i := slices.Index(q.ready, j.ID); i >= 0 {
+        q.ready = slices.Delete(q.ready, i, i+1)
+    }
+    j.NotBefore = time.Now().Add(q.retryDelay(j.Attempts))
+    q.delayed[j.ID] = j.NotBefore
+    q.signal()
+    return q.appendLocked(walRecord{Op: opNack, ID: j.ID, Until: j.NotBefore}, false)
+}
+
+// deadLocked moves j to the dead-letter file. If that fails j stays queued
+// and is retried after its retry delay. Caller holds q.mu.
+func (q *FileQueue) deadLocked(j *fileJob, reason string) error {
+    b, err := json.Marshal(DeadLetter{
+        ID:       j.ID,
+        Type:     j.Type,
+        TxID:     j.TxID,
+        Input:    j.Input,
+        Attempts: j.Attempts,
+        Error:    reason,
+        At:       time.Now(),
+    })
+    if err == nil {
+        _, err = q.dead.Write(append(b, '\n'))
+    }
+    if err != nil {
+        return errors.Join(fmt.Errorf("write dead letter: %w", err), q.delayLocked(j))
+    }
+    q.removeLocked(j.ID)
+    return q.appendLocked(walRecord{Op: opDead, ID: j.ID}, false)
+}
+
+// report passes err to OnError.
+func (q *FileQueue) report(err error) {
+    if err != nil && q.opts.OnError != nil {
+        q.opts.OnError(err)
+    }
+}
+
+// removeLocked forgets id and wakes Puts waiting for room. Caller holds q.mu.
+func (q *FileQueue) removeLocked(id string) {
+    delete(q.jobs, id)
+    delete(q.inflight, id)
+    delete(q.delayed, id)
+    if i := slices.Index(q.ready, id); i >= 0 {
+        q.ready = slices.Delete(q.ready, i, i+1)
+    }
+    close(q.space)
+    q.space = make(chan struct{})
+}
+
+func (q *FileQueue) signal() {
+    select {
+    case q.wake <- struct{}{}:
+    default:
+    }
+}
+
+// pump hands ready jobs to whichever worker receives from C first,
+// releases failed jobs once their retry delay is over and periodically
+// requeues jobs whose visibility timeout has passed.
+func (q *FileQueue) pump() {
+    defer q.wg.Done()
+    tick := time.NewTicker(min(max(q.opts.VisibilityTimeout/4, 10*time.Millisecond), time.Minute))
+    defer tick.Stop()
+    for {
+        job, ok := q.next()
+        if !ok {
+            var retry <-chan time.Time
+            var timer *time.Timer
+            if d, ok := q.nextRetry(); ok {
+                timer = time.NewTimer(d)
+                retry = timer.C
+            }
+            select {
+            case <-q.wake:
+            case <-retry:
+            case <-tick.C:
+                q.requeueExpired()
+            case <-q.quit:
+                return
+            }
+            if timer != nil {
+                timer.Stop()
+            }
+            continue
+        }
+        select {
+        case q.out <- job:
+            q.delivered(job.ID)
+        case <-tick.C:
+            q.unget(job.ID)
+            q.requeueExpired()
+        case <-q.quit:
+            q.unget(job.ID)
+            return
+        }
+    }
+}
+
+// next pops the first ready job and marks it in flight, dead-lettering jobs
+// that are already out of attempts.
+func (q *FileQueue) next() (Job, bool) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    q.releaseLocked(time.Now())
+    for len(q.ready) > 0 {
+        id := q.ready[0]
+        q.ready = q.ready[1:]
+        j := q.jobs[id]
+        if j.Attempts >= q.opts.MaxAttempts {
+            if err := q.deadLocked(j, "attempts exhausted"); err != nil {
+                q.report(fmt.Errorf("worker: dead-letter job %s: %w", id, err))
+            }
+            continue
+        }
+        j.Attempts++
+        q.inflight[id] = time.Now().Add(q.opts.VisibilityTimeout)
+        return Job{
+            ID:         j.ID,
+            Type:       parseJobType(j.Type),
+            TxID:       j.TxID,
+            Input:      j.Input,
+            EnqueuedAt: j.EnqueuedAt,
+        }, true
+    }
+    return Job{}, false
+}
+
+// releaseLocked moves failed jobs whose retry delay is over to the ready
+// list, earliest first. Caller holds q.mu.
+func (q *FileQueue) releaseLocked(now time.Time) {
+    var due []string
+    for id, at := range q.delayed {
+        if !at.After(now) {
+            due = append(due, id)
+        }
+    }
+    slices.SortFunc(due, func(a, b string) int { return q.delayed[a].Compare(q.delayed[b]) })
+    for _, id := range due {
+        delete(q.delayed, id)
+        q.ready = append(q.ready, id)
+    }
+}
+
+// nextRetry returns the time until the earliest delayed job is due.
+func (q *FileQueue) nextRetry() (time.Duration, bool) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    var earliest time.Time
+    for _, at := range q.delayed {
+        if earliest.IsZero() || at.Before(earliest) {
+            earliest = at
+        }
+    }
+    if earliest.IsZero() {
+        return 0, false
+    }
+    return time.Until(earliest), true
+}
+
+// unget puts back a job next returned but no worker received.
+func (q *FileQueue) unget(id string) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    if j, ok := q.jobs[id]; ok {
+        j.Attempts--
+        delete(q.inflight, id)
+        q.ready = append([]string{id}, q.ready...)
+    }
+}
+
+// delivered records the attempt so that crashes count against MaxAttempts.
+func (q *FileQueue) delivered(id string) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    if _, ok := q.jobs[id]; ok && !q.closed {
+        if err := q.appendLocked(walRecord{Op: opTake, ID: id}, false); err != nil {
+            q.report(fmt.Errorf("worker: record delivery of job %s: %w", id, err))
+        }
+    }
+}
+
+func (q *FileQueue) requeueExpired() {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    now := time.Now()
+    for id, deadline := range q.inflight {
+        if now.After(deadline) {
+            delete(q.inflight, id)
+            q.ready = append(q.ready, id)
+        }
+    }
+}
+
+func parseJobType(s string) JobType {
+    if n, err := strconv.Atoi(s); err == nil {
+        return JobType(n)
+    }
+    return NamedJobType(s)
+}
+
+func (q *FileQueue) Len() int {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    return len(q.ready) + len(q.delayed)
+}
+
+func (q *FileQueue) Cap() int {
+    return q.opts.MaxLen
+}
+
+// DeadLetters returns the jobs moved to the dead-letter file, oldest first.
+func (q *FileQueue) DeadLetters() ([]DeadLetter, error) {
+    f, err := os.Open(filepath.Join(q.dir, "dead.jsonl"))
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    var out []DeadLetter
+    sc := bufio.NewScanner(f)
+    sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+    for sc.Scan() {
+        var d DeadLetter
+        if json.Unmarshal(sc.Bytes(), &d) == nil {
+            out = append(out, d)
+        }
+    }
+    return out, sc.Err()
+}
+
+// Close stops deliveries and closes the files. Jobs still in flight stay in
+// the WAL and are delivered again by the next NewFileQueue.
+func (q *FileQueue) Close() error {
+    q.mu.Lock()
+    if q.closed {
+        q.mu.Unlock()
+        return nil
+    }
+    q.closed = true
+    close(q.space)
+    q.mu.Unlock()
+
+    close(q.quit)
+    q.wg.Wait()
+
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    return errors.Join(q.wal.Close(), q.dead.Close())
+}
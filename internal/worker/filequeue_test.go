@@ -0,0 +1,197 @@
+package worker
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/logger"
+)
+
+func recvJob(t *testing.T, q Queue) Job {
+    t.Helper()
+    select {
+    case job := <-q.C():
+        return job
+    case <-time.After(2 * time.Second):
+        t.Fatal("no job delivered")
+        return Job{}
+    }
+}
+
+func TestFileQueueRetryAndDeadLetter(t *testing.T) {
+    q, err := NewFileQueue(t.TempDir(), FileQueueOptions{MaxAttempts: 2, RetryDelay: time.Millisecond})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer q.Close()
+    if err := q.Put(context.Background(), Job{ID: "a", Type: JobTypeFunc, Input: "x"}); err != nil {
+        t.Fatal(err)
+    }
+
+    job := recvJob(t, q)
+    if job.ID != "a" || job.Type != JobTypeFunc || string(job.Input.(json.RawMessage)) != `"x"` {
+        t.Fatalf("delivered %+v", job)
+    }
+    q.Settle(job, errors.New("boom"))
+    q.Settle(recvJob(t, q), errors.New("boom again"))
+
+    dead, err := q.DeadLetters()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(dead) != 1 || dead[0].ID != "a" || dead[0].Attempts != 2 || dead[0].Error != "boom again" {
+        t.Fatalf("dead letters = %+v", dead)
+    }
+    if q.Len() != 0 {
+        t.Fatalf("Len = %d after dead letter", q.Len())
+    }
+}
+
+func TestFileQueueRetryDelay(t *testing.T) {
+    dir := t.TempDir()
+    opts := FileQueueOptions{RetryDelay: 100 * time.Millisecond, MaxRetryDelay: 150 * time.Millisecond}
+    q, err := NewFileQueue(dir, opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if d1, d2, d5 := q.retryDelay(1), q.retryDelay(2), q.retryDelay(5); d1 != 100*time.Millisecond || d2 != 150*time.Millisecond || d5 != 150*time.Millisecond {
+        t.Fatalf("retry delays %s, %s, %s; want 100ms, 150ms, 150ms", d1, d2, d5)
+    }
+    if err := q.Put(context.Background(), Job{ID: "a", Type: JobTypeExample, Input: 1}); err != nil {
+        t.Fatal(err)
+    }
+    failed := time.Now()
+    if err := q.Settle(recvJob(t, q), errors.New("boom")); err != nil {
+        t.Fatal(err)
+    }
+    if q.Len() != 1 {
+        t.Fatalf("Len = %d while the job waits to be retried", q.Len())
+    }
+    // the delay survives a restart
+    if err := q.Close(); err != nil {
+        t.Fatal(err)
+    }
+    q, err = NewFileQueue(dir, opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer q.Close()
+    job := recvJob(t, q)
+    if waited := time.Since(failed); job.ID != "a" || waited < 100*time.Millisecond {
+        t.Fatalf("job %s redelivered after %s, want a after 100ms", job.ID, waited)
+    }
+}
+
+func TestFileQueueSettleWriteError(t *testing.T) {
+    q, err := NewFileQueue(t.TempDir(), FileQueueOptions{MaxAttempts: 1, RetryDelay: time.Millisecond})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer q.Close()
+    if err := q.Put(context.Background(), Job{ID: "a", Type: JobTypeExample, Input: 1}); err != nil {
+        t.Fatal(err)
+    }
+    // the dead-letter file can no longer be written
+    q.dead.Close()
+    if err := q.Settle(recvJob(t, q), errors.New("boom")); err == nil {
+        t.Fatal("want error when the dead letter cannot be written")
+    }
+    if q.Len() != 1 {
+        t.Fatalf("Len = %d, want the job kept after the failed dead-letter write", q.Len())
+    }
+}
+
+func TestFileQueueSurvivesRestart(t *testing.T) {
+    dir := t.TempDir()
+    q, err := NewFileQueue(dir, FileQueueOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, id := range []string{"a", "b", "c"} {
+        if err := q.Put(context.Background(), Job{ID: id, Type: JobTypeExample, Input: id}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    q.Settle(recvJob(t, q), nil)
+    recvJob(t, q) // in flight when the process stops
+    if err := q.Close(); err != nil {
+        t.Fatal(err)
+    }
+
+    q, err = NewFileQueue(dir, FileQueueOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer q.Close()
+    if q.Len() != 2 {
+        t.Fatalf("Len after reopen = %d, want 2", q.Len())
+    }
+    got := map[string]bool{}
+    for i := 0; i < 2; i++ {
+        job := recvJob(t, q)
+        got[job.ID] = true
+        q.Settle(job, nil)
+    }
+    if !got["b"] || !got["c"] {
+        t.Fatalf("redelivered %v, want b and c", got)
+    }
+}
+
+func TestFileQueueVisibilityTimeout(t *testing.T) {
+    q, err := NewFileQueue(t.TempDir(), FileQueueOptions{VisibilityTimeout: 20 * time.Millisecond})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer q.Close()
+    if err := q.Put(context.Background(), Job{ID: "a", Type: JobTypeExample, Input: 1}); err != nil {
+        t.Fatal(err)
+    }
+    recvJob(t, q) // never settled
+    if job := recvJob(t, q); job.ID != "a" {
+        t.Fatalf("redelivered %q", job.ID)
+    }
+}
+
+func TestEnqueueAsync(t *testing.T) {
+    type payload struct{ N int }
+    reg := NewRegistry()
+    got := make(chan int, 1)
+    typ := reg.RegisterName(PoolDB, "payload", HandlerFunc(func(ctx context.Context, p payload) (struct{}, error) {
+        got <- p.N
+        return struct{}{}, nil
+    }))
+    lg, err := logger.New(t.TempDir(), "debug")
+    if err != nil {
+        t.Fatalf("logger: %v", err)
+    }
+    defer lg.Close()
+    q, err := NewFileQueue(t.TempDir(), FileQueueOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    pools := &Pools{
+        DBInput:  make(chan Job, 1),
+        Registry: reg,
+        Async:    map[Pool]Queue{PoolDB: q},
+    }
+    StartDBWorkers(context.Background(), 1, pools, lg)
+    defer pools.Shutdown(context.Background())
+
+    if err := pools.EnqueueAsync(context.Background(), PoolDB, Job{Type: typ, Input: payload{N: 7}}); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case n := <-got:
+        if n != 7 {
+            t.Fatalf("handler got %d", n)
+        }
+    case <-time.After(2 * time.Second):
+        t.Fatal("async job not handled")
+    }
+    if err := pools.EnqueueAsync(context.Background(), PoolMain, Job{Type: typ}); err == nil {
+        t.Fatal("want error for pool without async queue")
+    }
+}
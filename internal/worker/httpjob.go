@@ -0,0 +1,58 @@
+// # 외부 HTTP 대상 작업 타입 (httpclient 로 GET 호출)
+package worker
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "net/url"
+    "slices"
+    "strings"
+
+    "github.com/example/XXXDONGXXX/internal/httpclient"
+)
+
+// HTTPResponse is the Result data of a job registered with
+// RegisterHTTPTarget.
+type HTTPResponse struct {
+    Status int    `json:"status"`
+    Body   string `json:"body"`
+}
+
+// bodies of HTTP target responses are cut off past this many bytes
+const maxHTTPBody = 1 << 20
+
+// RegisterHTTPTarget registers an external pool job type named name whose
+// input is a path GETted below baseURL with c; paths with ".." segments
+// fail. The type name doubles as the
+// job's target, so name's breaker and rate limiter apply. A 5xx answer fails
+// the job. Names of built-in job types are refused.
+func RegisterHTTPTarget(r *Registry, c *httpclient.Client, name, baseURL string) (JobType, error) {
+    if t, ok := LookupJobType(name); ok && t < firstNamedType {
+        return 0, fmt.Errorf("worker: http target %s: name is a built-in job type", name)
+    }
+    base, err := url.Parse(baseURL)
+    if err != nil {
+        return 0, fmt.Errorf("worker: http target %s: %w", name, err)
+    }
+    return r.RegisterName(PoolExternal, name, HandlerFunc(func(ctx context.Context, path string) (HTTPResponse, error) {
+        // checked on the decoded path so that escaped dots are caught too
+        u := base.JoinPath(path)
+        if slices.Contains(strings.Split(u.Path, "/"), "..") || !strings.HasPrefix(u.Path, strings.TrimSuffix(base.Path, "/")+"/") {
+            return HTTPResponse{}, fmt.Errorf("worker: %s: path %q leaves the target's base URL", name, path)
+        }
+        resp, err := c.Get(ctx, u.String())
+        if err != nil {
+            return HTTPResponse{}, err
+        }
+        defer resp.Body.Close()
+        body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+        if err != nil {
+            return HTTPResponse{}, fmt.Errorf("worker: %s: read response: %w", name, err)
+        }
+        if resp.StatusCode >= 500 {
+            return HTTPResponse{}, fmt.Errorf("worker: %s answered %s", name, resp.Status)
+        }
+        return HTTPResponse{Status: resp.StatusCode, Body: string(body)}, nil
+    })), nil
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/config"
+    "github.com/example/XXXDONGXXX/internal/httpclient"
+    "github.com/example/XXXDONGXXX/internal/logger"
+)
+
+func TestHTTPTarget(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/v1/down" {
+            w.WriteHeader(http.StatusBadGateway)
+            return
+        }
+        w.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Request-Id")))
+    }))
+    defer srv.Close()
+    lg, err := logger.New(t.TempDir(), "debug")
+    if err != nil {
+        t.Fatalf("logger: %v", err)
+    }
+    t.Cleanup(lg.Close)
+    client := httpclient.New(config.Config{External: config.ExternalConfig{HTTP: config.HTTPClientConfig{TimeoutMs: 1000, MaxAttempts: 1}}}, lg)
+
+    reg := NewRegistry()
+    partner, err := RegisterHTTPTarget(reg, client, "partner", srv.URL+"/v1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    // same base URL, no breaker, for the rejected paths below
+    paths, err := RegisterHTTPTarget(reg, client, "partner-paths", srv.URL+"/v1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, name := range []string{"example", "func"} {
+        if _, err := RegisterHTTPTarget(reg, client, name, srv.URL); err == nil {
+            t.Fatalf("RegisterHTTPTarget(%q) replaced a built-in type", name)
+        }
+    }
+    pools := startTestPools(t, reg)
+    pools.Breakers = NewBreakers([]string{"partner"}, BreakerConfig{MinRequests: 1, CoolDown: time.Hour}, nil)
+
+    res := send(pools, PoolExternal, partner, "/items/1")
+    if out, _ := res.Data.(HTTPResponse); res.Err != nil || out.Status != http.StatusOK || out.Body != "/v1/items/1 tx" {
+        t.Fatalf("http job = %+v, %v", res.Data, res.Err)
+    }
+    for _, path := range []string{"../admin", "items/../../admin", "%2e%2e/admin", "a%2F..%2F..%2F..%2Fadmin"} {
+        if res := send(pools, PoolExternal, paths, path); res.Err == nil || !strings.Contains(res.Err.Error(), "leaves the target") {
+            t.Fatalf("http job for %q = %+v, %v; want it rejected", path, res.Data, res.Err)
+        }
+    }
+    res = send(pools, PoolExternal, partner, "/down")
+    if res.Err == nil || !strings.Contains(res.Err.Error(), "502") {
+        t.Fatalf("http job on 502 err = %v", res.Err)
+    }
+    if st := pools.Breakers.Get("partner").Status(); st.State != BreakerOpen {
+        t.Fatalf("partner breaker = %+v, want open after the 502", st)
+    }
+}
@@ -0,0 +1,250 @@
+// # 비동기 작업 상태 저장소 (제출, 상태 조회, 취소, TTL 정리)
+package worker
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/txid"
+)
+
+var (
+    ErrJobNotFound = errors.New("worker: job not found")
+    ErrJobFinished = errors.New("worker: job already finished")
+)
+
+type JobState string
+
+const (
+    JobQueued    JobState = "queued"
+    JobRunning   JobState = "running"
+    JobSucceeded JobState = "succeeded"
+    JobFailed    JobState = "failed"
+    JobCanceled  JobState = "canceled"
+)
+
+// Finished reports whether the job has reached a final state.
+func (s JobState) Finished() bool {
+    return s == JobSucceeded || s == JobFailed || s == JobCanceled
+}
+
+// JobStatus is a snapshot of a job tracked by a JobStore.
+type JobStatus struct {
+    ID         string      `json:"id"`
+    Pool       Pool        `json:"pool"`
+    Type       string      `json:"type"`
+    TxID       string      `json:"txId"`
+    State      JobState    `json:"state"`
+    Result     interface{} `json:"result,omitempty"`
+    Error      string      `json:"error,omitempty"`
+    CreatedAt  time.Time   `json:"createdAt"`
+    StartedAt  *time.Time  `json:"startedAt,omitempty"`
+    FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+}
+
+// JobStore runs jobs in the background and keeps their status for polling.
+// Finished jobs are forgotten ttl after they finish (see Run).
+type JobStore struct {
+    pools *Pools
+    ttl   time.Duration
+    // closed by Close; pending jobs stop waiting for their results
+    done chan struct{}
+
+    mu     sync.Mutex
+    jobs   map[string]*trackedJob
+    closed bool
+}
+
+type trackedJob struct {
+    status JobStatus
+    cancel context.CancelFunc
+}
+
+func NewJobStore(pools *Pools, ttl time.Duration) *JobStore {
+    return &JobStore{pools: pools, ttl: ttl, done: make(chan struct{}), jobs: make(map[string]*trackedJob)}
+}
+
+// Start queues a job of type typ on pool and returns without waiting for
+// it. The job keeps ctx's txid and priority but not its cancellation or
+// deadline, so it outlives the request; use Cancel to stop it. Start fails
+// like Submit when the queue is full or the pools or the store are shutting
+// down, and with an *UnknownJobTypeError when pool has no handler for typ.
+func (s *JobStore) Start(ctx context.Context, pool Pool, typ JobType, in interface{}) (JobStatus, error) {
+    if _, err := s.pools.registry().lookup(pool, typ); err != nil {
+        return JobStatus{}, err
+    }
+    id := txid.NewID()
+    tx := txid.FromContext(ctx)
+    if tx == "" {
+        tx = id
+    }
+    jctx, cancel := context.WithCancel(txid.WithTxID(context.WithoutCancel(ctx), tx))
+    res := make(chan Result, 1)
+    job := Job{
+        ID:       id,
+        Type:     typ,
+        TxID:     tx,
+        Ctx:      jctx,
+        Input:    in,
+        Result:   res,
+        Priority: PriorityFromContext(ctx),
+        OnStart:  func() { s.started(id) },
+    }
+
+    t := &trackedJob{
+        status: JobStatus{
+            ID:        id,
+            Pool:      pool,
+            Type:      typ.String(),
+            TxID:      tx,
+            State:     JobQueued,
+            CreatedAt: time.Now(),
+        },
+        cancel: cancel,
+    }
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        cancel()
+        return JobStatus{}, ErrShutdown
+    }
+    s.jobs[id] = t
+    st := t.status
+    s.mu.Unlock()
+
+    if err := s.pools.enqueue(ctx, pool, job, EnqueueTimeout); err != nil {
+        cancel()
+        s.mu.Lock()
+        delete(s.jobs, id)
+        s.mu.Unlock()
+        return JobStatus{}, err
+    }
+    go s.finish(id, res)
+    return st, nil
+}
+
+func (s *JobStore) started(id string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if t, ok := s.jobs[id]; ok && t.status.State == JobQueued {
+        now := time.Now()
+        t.status.State = JobRunning
+        t.status.StartedAt = &now
+    }
+}
+
+// finish records the job's result unless it was cancelled meanwhile. It
+// gives up when the store is closed, as the pools may have stopped without
+// answering.
+func (s *JobStore) finish(id string, res <-chan Result) {
+    var r Result
+    select {
+    case r = <-res:
+    case <-s.done:
+        return
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    t, ok := s.jobs[id]
+    if !ok {
+        return
+    }
+    t.cancel()
+    if t.status.State.Finished() {
+        return
+    }
+    now := time.Now()
+    t.status.FinishedAt = &now
+    if r.Err != nil {
+        t.status.State = JobFailed
+        t.status.Error = r.Err.Error()
+        return
+    }
+    t.status.State = JobSucceeded
+    t.status.Result = r.Data
+}
+
+// Get returns the status of job id.
+func (s *JobStore) Get(id string) (JobStatus, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    t, ok := s.jobs[id]
+    if !ok {
+        return JobStatus{}, ErrJobNotFound
+    }
+    return t.status, nil
+}
+
+// Cancel marks job id as cancelled and cancels its Job.Ctx. A queued job
+// is still taken off the queue later but its result is discarded; a
+// running one stops if its handler honours Job.Ctx.
+func (s *JobStore) Cancel(id string) (JobStatus, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    t, ok := s.jobs[id]
+    if !ok {
+        return JobStatus{}, ErrJobNotFound
+    }
+    if t.status.State.Finished() {
+        return t.status, ErrJobFinished
+    }
+    now := time.Now()
+    t.status.State = JobCanceled
+    t.status.FinishedAt = &now
+    t.cancel()
+    return t.status, nil
+}
+
+// Close fails the jobs that are still queued or running with ErrShutdown,
+// cancels them and stops waiting for their results. Call it once the pools
+// have shut down; later Starts fail with ErrShutdown.
+func (s *JobStore) Close() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return
+    }
+    s.closed = true
+    close(s.done)
+    now := time.Now()
+    for _, t := range s.jobs {
+        if t.status.State.Finished() {
+            continue
+        }
+        t.status.State = JobFailed
+        t.status.Error = ErrShutdown.Error()
+        t.status.FinishedAt = &now
+        t.cancel()
+    }
+}
+
+// Sweep forgets jobs that finished more than ttl before now and returns how
+// many it removed.
+func (s *JobStore) Sweep(now time.Time) int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    n := 0
+    for id, t := range s.jobs {
+        if t.status.FinishedAt != nil && now.Sub(*t.status.FinishedAt) > s.ttl {
+            delete(s.jobs, id)
+            n++
+        }
+    }
+    return n
+}
+
+// Run sweeps expired jobs periodically until ctx is done.
+func (s *JobStore) Run(ctx context.Context) {
+    tick := time.NewTicker(min(max(s.ttl/2, time.Second), time.Minute))
+    defer tick.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case now := <-tick.C:
+            s.Sweep(now)
+        }
+    }
+}
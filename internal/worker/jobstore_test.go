@@ -0,0 +1,126 @@
+package worker
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+)
+
+func waitState(t *testing.T, s *JobStore, id string, want JobState) JobStatus {
+    t.Helper()
+    deadline := time.Now().Add(2 * time.Second)
+    for {
+        st, err := s.Get(id)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if st.State == want {
+            return st
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("job %s state = %s, want %s", id, st.State, want)
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+}
+
+func TestJobStore(t *testing.T) {
+    reg := NewRegistry()
+    release := make(chan struct{})
+    block := reg.RegisterName(PoolMain, "block", func(ctx context.Context, job Job) (interface{}, error) {
+        select {
+        case <-release:
+            return "done", nil
+        case <-job.Ctx.Done():
+            return nil, job.Ctx.Err()
+        }
+    })
+    pools := startTestPools(t, reg)
+    s := NewJobStore(pools, time.Minute)
+
+    st, err := s.Start(context.Background(), PoolMain, block, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if st.State != JobQueued || st.ID == "" || st.TxID == "" {
+        t.Fatalf("Start = %+v", st)
+    }
+    waitState(t, s, st.ID, JobRunning)
+    close(release)
+    if got := waitState(t, s, st.ID, JobSucceeded); got.Result != "done" || got.FinishedAt == nil {
+        t.Fatalf("finished job = %+v", got)
+    }
+    if _, err := s.Cancel(st.ID); !errors.Is(err, ErrJobFinished) {
+        t.Fatalf("Cancel finished job err = %v", err)
+    }
+
+    if _, err := s.Start(context.Background(), PoolDB, block, nil); err == nil {
+        t.Fatal("want error for type without a db handler")
+    }
+
+    if n := s.Sweep(time.Now()); n != 0 {
+        t.Fatalf("Sweep removed %d fresh jobs", n)
+    }
+    if n := s.Sweep(time.Now().Add(2 * time.Minute)); n != 1 {
+        t.Fatalf("Sweep removed %d jobs, want 1", n)
+    }
+    if _, err := s.Get(st.ID); !errors.Is(err, ErrJobNotFound) {
+        t.Fatalf("Get after sweep err = %v", err)
+    }
+}
+
+func TestJobStoreCancel(t *testing.T) {
+    reg := NewRegistry()
+    block := reg.RegisterName(PoolMain, "block", func(ctx context.Context, job Job) (interface{}, error) {
+        <-job.Ctx.Done()
+        return nil, job.Ctx.Err()
+    })
+    pools := startTestPools(t, reg)
+    s := NewJobStore(pools, time.Minute)
+
+    st, err := s.Start(context.Background(), PoolMain, block, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    waitState(t, s, st.ID, JobRunning)
+    if st, err = s.Cancel(st.ID); err != nil || st.State != JobCanceled {
+        t.Fatalf("Cancel = %+v, %v", st, err)
+    }
+    // the handler returns once Job.Ctx is cancelled; the state stays cancelled
+    time.Sleep(20 * time.Millisecond)
+    if st, _ = s.Get(st.ID); st.State != JobCanceled {
+        t.Fatalf("state after handler returned = %s", st.State)
+    }
+    if _, err := s.Cancel("nope"); !errors.Is(err, ErrJobNotFound) {
+        t.Fatalf("Cancel unknown err = %v", err)
+    }
+}
+
+func TestJobStoreClose(t *testing.T) {
+    reg := NewRegistry()
+    echo := reg.RegisterName(PoolMain, "echo", func(ctx context.Context, job Job) (interface{}, error) {
+        return nil, nil
+    })
+    // no workers: the job stays queued and its result never comes
+    pools := &Pools{MainInput: make(chan Job, 1), Registry: reg}
+    s := NewJobStore(pools, time.Minute)
+
+    st, err := s.Start(context.Background(), PoolMain, echo, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    s.Close()
+    st, err = s.Get(st.ID)
+    if err != nil || st.State != JobFailed || st.Error != ErrShutdown.Error() || st.FinishedAt == nil {
+        t.Fatalf("after Close = %+v, %v", st, err)
+    }
+    job := <-pools.MainInput
+    if job.Ctx.Err() == nil {
+        t.Fatal("queued job not cancelled by Close")
+    }
+    if _, err := s.Start(context.Background(), PoolMain, echo, nil); !errors.Is(err, ErrShutdown) {
+        t.Fatalf("Start after Close err = %v", err)
+    }
+    s.Close()
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/example/XXXDONGXXX/internal/metrics"
+)
+
+// counters are process-wide, so each run (go test -count) uses fresh types
+var scrapeRuns int
+
+func TestMetricsScrape(t *testing.T) {
+    scrapeRuns++
+    okName := fmt.Sprintf("scrape-ok-%d", scrapeRuns)
+    failName := fmt.Sprintf("scrape-fail-%d", scrapeRuns)
+    reg := NewRegistry()
+    ok := reg.RegisterName(PoolDB, okName, func(ctx context.Context, job Job) (interface{}, error) {
+        return nil, nil
+    })
+    failed := reg.RegisterName(PoolDB, failName, func(ctx context.Context, job Job) (interface{}, error) {
+        return nil, errors.New("boom")
+    })
+    pools := startTestPools(t, reg)
+    metrics.SetPoolStats(pools.Stats)
+    t.Cleanup(func() { metrics.SetPoolStats(nil) })
+
+    send(pools, PoolDB, ok, nil)
+    send(pools, PoolDB, ok, nil)
+    send(pools, PoolDB, failed, nil)
+
+    rec := httptest.NewRecorder()
+    metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+    body := rec.Body.String()
+    for _, want := range []string{
+        "# TYPE xxxdongxxx_worker_workers gauge",
+        `xxxdongxxx_worker_workers{pool="main"} 1`,
+        `xxxdongxxx_worker_workers{pool="db"} 1`,
+        `xxxdongxxx_worker_workers{pool="external"} 1`,
+        `xxxdongxxx_worker_queue_length{pool="db"} 0`,
+        `xxxdongxxx_worker_queue_capacity{pool="db"} 1`,
+        `xxxdongxxx_worker_jobs_processed_total{pool="db",type="` + okName + `"} 2`,
+        `xxxdongxxx_worker_jobs_processed_total{pool="db",type="` + failName + `"} 1`,
+        `xxxdongxxx_worker_jobs_failed_total{pool="db",type="` + failName + `"} 1`,
+        `xxxdongxxx_worker_job_duration_seconds_count{pool="db",type="` + okName + `"} 2`,
+    } {
+        if !strings.Contains(body, want+"\n") {
+            t.Errorf("scrape is missing %q", want)
+        }
+    }
+    if strings.Contains(body, `xxxdongxxx_worker_jobs_failed_total{pool="db",type="`+okName+`"}`) {
+        t.Error("scrape-ok jobs counted as failed")
+    }
+}
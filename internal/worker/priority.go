@@ -0,0 +1,146 @@
+// # main 풀 우선순위 레인 (high/normal/low, 가중 라운드로빈)
+package worker
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+)
+
+// Priority selects the lane a job waits in on the main pool. The other
+// pools have a single queue and ignore it.
+type Priority int
+
+const (
+    PriorityNormal Priority = iota
+    PriorityHigh
+    PriorityLow
+    numPriorities
+)
+
+func (p Priority) String() string {
+    switch p {
+    case PriorityHigh:
+        return "high"
+    case PriorityLow:
+        return "low"
+    }
+    return "normal"
+}
+
+// Cap returns p, or max when p is more urgent than max.
+func (p Priority) Cap(max Priority) Priority {
+    if p.urgency() > max.urgency() {
+        return max
+    }
+    return p
+}
+
+func (p Priority) urgency() int {
+    switch p {
+    case PriorityHigh:
+        return 2
+    case PriorityLow:
+        return 0
+    }
+    return 1
+}
+
+// ParsePriority accepts "high", "normal" and "low" in any case.
+func ParsePriority(s string) (Priority, error) {
+    switch strings.ToLower(strings.TrimSpace(s)) {
+    case "high":
+        return PriorityHigh, nil
+    case "normal":
+        return PriorityNormal, nil
+    case "low":
+        return PriorityLow, nil
+    }
+    return PriorityNormal, fmt.Errorf("worker: unknown priority %q", s)
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a ctx that makes Submit send jobs at p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+    return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority set by WithPriority, or
+// PriorityNormal.
+func PriorityFromContext(ctx context.Context) Priority {
+    if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+        return p
+    }
+    return PriorityNormal
+}
+
+// A group's lanes are indexed by Priority, followed by the pool's async
+// queue (see Pools.Async).
+const (
+    laneAsync = int(numPriorities)
+    numLanes  = laneAsync + 1
+)
+
+// laneOrder is the weighted round robin followed while lanes are backed up:
+// out of every 7 jobs 4 come from high, 2 from normal and 1 from low, so a
+// flood of bulk work slows interactive jobs down but never starves them.
+// The async queue gets an eighth slot, which is skipped while it is empty so
+// the priority lanes keep their 4:2:1 share.
+var laneOrder = [...]int{
+    int(PriorityHigh), int(PriorityNormal), int(PriorityHigh), int(PriorityLow),
+    int(PriorityHigh), int(PriorityNormal), int(PriorityHigh), laneAsync,
+}
+
+// lanePreference is tried in order when the scheduled lane is empty.
+var lanePreference = [...]int{int(PriorityHigh), int(PriorityNormal), int(PriorityLow), laneAsync}
+
+// take returns the next job and its lane for a worker whose round robin
+// position is *turn, blocking until one arrives. It returns false once the
+// worker has to stop or timeout fires; a nil timeout never fires. Lanes the
+// pool does not have are nil and never ready.
+func (g *group) take(turn *int, done, quit <-chan struct{}, timeout <-chan time.Time) (Job, int, bool) {
+    first := laneOrder[*turn%len(laneOrder)]
+    *turn++
+    if job, ok := tryRecv(g.lanes[first]); ok {
+        return job, first, true
+    }
+    if first == laneAsync {
+        first = laneOrder[*turn%len(laneOrder)]
+        *turn++
+        if job, ok := tryRecv(g.lanes[first]); ok {
+            return job, first, true
+        }
+    }
+    for _, lane := range lanePreference {
+        if job, ok := tryRecv(g.lanes[lane]); ok {
+            return job, lane, true
+        }
+    }
+
+    select {
+    case <-g.ctx.Done():
+    case <-done:
+    case <-quit:
+    case <-timeout:
+    case job := <-g.lanes[PriorityHigh]:
+        return job, int(PriorityHigh), true
+    case job := <-g.lanes[PriorityNormal]:
+        return job, int(PriorityNormal), true
+    case job := <-g.lanes[PriorityLow]:
+        return job, int(PriorityLow), true
+    case job := <-g.lanes[laneAsync]:
+        return job, laneAsync, true
+    }
+    return Job{}, 0, false
+}
+
+func tryRecv(in <-chan Job) (Job, bool) {
+    select {
+    case job := <-in:
+        return job, true
+    default:
+        return Job{}, false
+    }
+}
@@ -0,0 +1,68 @@
+// # 비동기 작업 큐 인터페이스 (메모리 채널 / 파일 WAL)
+package worker
+
+import (
+    "context"
+    "errors"
+    "fmt"
+)
+
+// Queue holds fire-and-forget jobs for a pool's workers. Jobs on a Queue
+// have no Result channel; the worker reports the handler's outcome through
+// Settle instead.
+type Queue interface {
+    // Put adds job, waiting for room until ctx ends.
+    Put(ctx context.Context, job Job) error
+    // C delivers jobs to the workers.
+    C() <-chan Job
+    // Settle is called with the handler's error once a job taken from C
+    // has been handled. It fails if the outcome could not be recorded.
+    Settle(job Job, err error) error
+    // Len is the number of jobs waiting for delivery.
+    Len() int
+    // Cap is the queue's capacity, 0 when unbounded.
+    Cap() int
+    // Close releases the queue after the workers have stopped.
+    Close() error
+}
+
+// ChanQueue is the in-memory Queue: a buffered channel. Jobs are lost on
+// restart and failed jobs are not redelivered.
+type ChanQueue chan Job
+
+func (q ChanQueue) Put(ctx context.Context, job Job) error {
+    select {
+    case q <- job:
+        return nil
+    case <-ctx.Done():
+        return ctxErr(ctx)
+    }
+}
+
+func (q ChanQueue) C() <-chan Job           { return q }
+func (q ChanQueue) Settle(Job, error) error { return nil }
+func (q ChanQueue) Len() int                { return len(q) }
+func (q ChanQueue) Cap() int                { return cap(q) }
+func (q ChanQueue) Close() error            { return nil }
+
+// EnqueueAsync puts a fire-and-forget job on pool's async queue. job.Result
+// must be nil; with a FileQueue, job.Input must also be JSON-encodable and
+// handlers receive it as json.RawMessage (HandlerFunc decodes it).
+func (p *Pools) EnqueueAsync(ctx context.Context, pool Pool, job Job) error {
+    if job.Result != nil {
+        return errors.New("worker: async jobs cannot have a Result channel")
+    }
+    q := p.Async[pool]
+    if q == nil {
+        return fmt.Errorf("worker: %s pool has no async queue", pool)
+    }
+    p.mu.Lock()
+    done := p.doneLocked(pool)
+    p.mu.Unlock()
+    select {
+    case <-done:
+        return ErrShutdown
+    default:
+    }
+    return q.Put(ctx, job)
+}
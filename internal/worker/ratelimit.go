@@ -0,0 +1,124 @@
+// # 외부 대상별 토큰 버킷 속도 제한 (대기 예산, Job.Ctx 마감 준수)
+package worker
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/clock"
+    "github.com/example/XXXDONGXXX/internal/metrics"
+)
+
+// RateLimitError is the Result.Err of a job that could not get a token for
+// its Target within the limiter's wait budget or its Job.Ctx deadline.
+type RateLimitError struct {
+    Target string
+    // Wait is how long the job would have had to wait.
+    Wait time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+    return fmt.Sprintf("worker: rate limit for %s exceeded (would wait %s)", e.Target, e.Wait)
+}
+
+// LimiterConfig sets a target's token bucket.
+type LimiterConfig struct {
+    // Rate is the number of tokens added per second; it must be positive.
+    Rate float64
+    // Burst is the bucket size, at least 1.
+    Burst int
+    // MaxWait is the longest a job waits for a token, default 1 second.
+    MaxWait time.Duration
+}
+
+// Limiter is a token bucket for one downstream target.
+type Limiter struct {
+    target  string
+    rate    float64
+    burst   float64
+    maxWait time.Duration
+    clock   clock.Clock
+
+    mu     sync.Mutex
+    tokens float64
+    last   time.Time
+}
+
+func NewLimiter(target string, cfg LimiterConfig, clk clock.Clock) *Limiter {
+    if clk == nil {
+        clk = clock.Real{}
+    }
+    if cfg.MaxWait <= 0 {
+        cfg.MaxWait = time.Second
+    }
+    burst := float64(max(cfg.Burst, 1))
+    return &Limiter{
+        target:  target,
+        rate:    cfg.Rate,
+        burst:   burst,
+        maxWait: cfg.MaxWait,
+        clock:   clk,
+        tokens:  burst,
+        last:    clk.Now(),
+    }
+}
+
+// Wait takes a token, sleeping until one is available. It fails with a
+// *RateLimitError without waiting when the token would come later than
+// MaxWait or ctx's deadline, and with ctx's error if ctx ends meanwhile.
+func (l *Limiter) Wait(ctx context.Context) error {
+    l.mu.Lock()
+    now := l.clock.Now()
+    l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+    l.last = now
+    l.tokens--
+    var wait time.Duration
+    if l.tokens < 0 {
+        wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+    }
+    deadline, ok := ctx.Deadline()
+    if wait > l.maxWait || ok && now.Add(wait).After(deadline) {
+        l.tokens++
+        l.mu.Unlock()
+        metrics.IncRateLimited(l.target)
+        return &RateLimitError{Target: l.target, Wait: wait}
+    }
+    l.mu.Unlock()
+    if wait == 0 {
+        return nil
+    }
+
+    metrics.ObserveRateLimitWait(l.target, wait)
+    t := l.clock.NewTimer(wait)
+    defer t.Stop()
+    select {
+    case <-t.C():
+        return nil
+    case <-ctx.Done():
+        l.mu.Lock()
+        l.tokens++
+        l.mu.Unlock()
+        return ctxErr(ctx)
+    }
+}
+
+// Limiters holds the limiters of the configured targets; other targets are
+// not limited.
+type Limiters struct {
+    m map[string]*Limiter
+}
+
+func NewLimiters(targets map[string]LimiterConfig, clk clock.Clock) *Limiters {
+    ls := &Limiters{m: make(map[string]*Limiter, len(targets))}
+    for name, cfg := range targets {
+        ls.m[name] = NewLimiter(name, cfg, clk)
+    }
+    return ls
+}
+
+// Get returns target's limiter, or nil when target is not limited.
+func (ls *Limiters) Get(target string) *Limiter {
+    return ls.m[target]
+}
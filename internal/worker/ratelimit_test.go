@@ -0,0 +1,72 @@
+package worker
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/clock"
+)
+
+func TestLimiter(t *testing.T) {
+    fc := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+    l := NewLimiter("partner", LimiterConfig{Rate: 2, Burst: 2, MaxWait: time.Second}, fc)
+    ctx := context.Background()
+
+    for i := 0; i < 2; i++ {
+        if err := l.Wait(ctx); err != nil {
+            t.Fatalf("burst token %d: %v", i, err)
+        }
+    }
+    done := make(chan error, 1)
+    go func() { done <- l.Wait(ctx) }()
+    fc.BlockUntil(1)
+    fc.Advance(500 * time.Millisecond)
+    if err := <-done; err != nil {
+        t.Fatalf("waited token: %v", err)
+    }
+
+    // the bucket is empty: two more tokens are 0.5s and 1s away, a third
+    // would exceed MaxWait
+    go func() { done <- l.Wait(ctx) }()
+    fc.BlockUntil(1)
+    go func() { done <- l.Wait(ctx) }()
+    fc.BlockUntil(2)
+    var rl *RateLimitError
+    if err := l.Wait(ctx); !errors.As(err, &rl) || rl.Target != "partner" || rl.Wait != 1500*time.Millisecond {
+        t.Fatalf("over budget err = %v", err)
+    }
+    fc.Advance(time.Second)
+    <-done
+    <-done
+
+    deadline, cancel := context.WithDeadline(ctx, fc.Now().Add(100*time.Millisecond))
+    defer cancel()
+    if err := l.Wait(deadline); !errors.As(err, &rl) {
+        t.Fatalf("past ctx deadline err = %v", err)
+    }
+}
+
+func TestLimiterInPool(t *testing.T) {
+    reg := NewRegistry()
+    partner := reg.RegisterName(PoolExternal, "partner", func(ctx context.Context, job Job) (interface{}, error) {
+        return "ok", nil
+    })
+    pools := startTestPools(t, reg)
+    pools.Limiters = NewLimiters(map[string]LimiterConfig{
+        "partner": {Rate: 0.001, Burst: 1, MaxWait: 10 * time.Millisecond},
+    }, nil)
+
+    if res := send(pools, PoolExternal, partner, nil); res.Err != nil {
+        t.Fatal(res.Err)
+    }
+    var rl *RateLimitError
+    if res := send(pools, PoolExternal, partner, nil); !errors.As(res.Err, &rl) {
+        t.Fatalf("second job err = %v", res.Err)
+    }
+    // other targets are not limited
+    if res := send(pools, PoolExternal, JobTypeExample, "x"); res.Err != nil {
+        t.Fatal(res.Err)
+    }
+}
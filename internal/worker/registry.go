@@ -0,0 +1,231 @@
+// # 작업 타입별 핸들러 등록 (main/db/external 풀)
+package worker
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "sync"
+)
+
+// Handler processes one job on a worker and returns the Result data. ctx is
+// the job's Job.Ctx, also cancelled when the worker stops, and carries its
+// txid.
+type Handler func(ctx context.Context, job Job) (interface{}, error)
+
+// HandlerFunc adapts a function over a concrete input type to a Handler.
+// json.RawMessage input, as delivered by FileQueue, is decoded into an In.
+// A job whose Input is not an In fails with an error instead of panicking.
+func HandlerFunc[In, Out any](fn func(ctx context.Context, in In) (Out, error)) Handler {
+    return func(ctx context.Context, job Job) (interface{}, error) {
+        in, err := decodeInput[In](job)
+        if err != nil {
+            return nil, err
+        }
+        return fn(ctx, in)
+    }
+}
+
+func decodeInput[In any](job Job) (In, error) {
+    in, ok := job.Input.(In)
+    if raw, isRaw := job.Input.(json.RawMessage); isRaw && !ok {
+        if err := json.Unmarshal(raw, &in); err != nil {
+            return in, fmt.Errorf("worker: job tx=%s: decode input: %w", job.TxID, err)
+        }
+        ok = true
+    }
+    if !ok {
+        return in, fmt.Errorf("worker: job tx=%s: input is %T, want %T", job.TxID, job.Input, in)
+    }
+    return in, nil
+}
+
+// UnknownJobTypeError is returned in Result.Err for a job whose type has no
+// handler on the pool it was sent to.
+type UnknownJobTypeError struct {
+    Pool Pool
+    Type JobType
+    Name string
+}
+
+func (e *UnknownJobTypeError) Error() string {
+    if e.Name != "" {
+        return fmt.Sprintf("worker: no handler for job type %s (%d) on %s pool", e.Name, e.Type, e.Pool)
+    }
+    return fmt.Sprintf("worker: no handler for job type %d on %s pool", e.Type, e.Pool)
+}
+
+// named job types are allocated from here so they never collide with the
+// built-in constants
+const firstNamedType JobType = 1 << 16
+
+var jobTypes = struct {
+    sync.RWMutex
+    byName map[string]JobType
+    names  map[JobType]string
+    next   JobType
+}{
+    byName: map[string]JobType{"example": JobTypeExample, "func": JobTypeFunc},
+    names:  map[JobType]string{JobTypeExample: "example", JobTypeFunc: "func"},
+    next:   firstNamedType,
+}
+
+// NamedJobType returns the JobType for name, allocating one the first time
+// the name is seen. Names are process-wide, so the same name always maps to
+// the same type.
+func NamedJobType(name string) JobType {
+    jobTypes.Lock()
+    defer jobTypes.Unlock()
+    if t, ok := jobTypes.byName[name]; ok {
+        return t
+    }
+    t := jobTypes.next
+    jobTypes.next++
+    jobTypes.byName[name] = t
+    jobTypes.names[t] = name
+    return t
+}
+
+// LookupJobType returns the JobType named name, if there is one.
+func LookupJobType(name string) (JobType, bool) {
+    jobTypes.RLock()
+    defer jobTypes.RUnlock()
+    t, ok := jobTypes.byName[name]
+    return t, ok
+}
+
+// String returns the name of t, or its number when it has none.
+func (t JobType) String() string {
+    if n := t.name(); n != "" {
+        return n
+    }
+    return fmt.Sprint(int(t))
+}
+
+func (t JobType) name() string {
+    jobTypes.RLock()
+    defer jobTypes.RUnlock()
+    return jobTypes.names[t]
+}
+
+// Registry maps job types to handlers, separately for each pool. It is safe
+// to register handlers while workers are running.
+type Registry struct {
+    mu       sync.RWMutex
+    handlers map[Pool]map[JobType]Handler
+    batch    map[Pool]map[JobType]BatchHandler
+}
+
+// DefaultRegistry is used by Pools whose Registry is nil.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns a registry with the built-in types: JobTypeFunc on
+// every pool and the JobTypeExample pipeline (main) and echo handlers.
+func NewRegistry() *Registry {
+    r := &Registry{
+        handlers: make(map[Pool]map[JobType]Handler),
+        batch:    make(map[Pool]map[JobType]BatchHandler),
+    }
+    for _, p := range []Pool{PoolMain, PoolDB, PoolExternal} {
+        r.Register(p, JobTypeFunc, handleFunc)
+    }
+    r.Register(PoolMain, JobTypeExample, handleMainPipeline)
+    r.Register(PoolDB, JobTypeExample, handleEcho)
+    r.Register(PoolExternal, JobTypeExample, handleEcho)
+    return r
+}
+
+// Register sets the handler for t on pool, replacing any previous one.
+func (r *Registry) Register(pool Pool, t JobType, h Handler) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    m := r.handlers[pool]
+    if m == nil {
+        m = make(map[JobType]Handler)
+        r.handlers[pool] = m
+    }
+    m[t] = h
+    delete(r.batch[pool], t)
+}
+
+// RegisterBatch sets a batch handler for t on pool, used when the pool has
+// batching enabled (see Pools.Batch). Otherwise jobs of type t are passed
+// to h one at a time.
+func (r *Registry) RegisterBatch(pool Pool, t JobType, h BatchHandler) {
+    r.Register(pool, t, func(ctx context.Context, job Job) (interface{}, error) {
+        res, err := h(ctx, []Job{job})
+        if err != nil {
+            return nil, err
+        }
+        if len(res) != 1 {
+            return nil, fmt.Errorf("worker: batch handler returned %d results for 1 job", len(res))
+        }
+        return res[0].Data, res[0].Err
+    })
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    m := r.batch[pool]
+    if m == nil {
+        m = make(map[JobType]BatchHandler)
+        r.batch[pool] = m
+    }
+    m[t] = h
+}
+
+// RegisterBatchName is RegisterBatch for NamedJobType(name).
+func (r *Registry) RegisterBatchName(pool Pool, name string, h BatchHandler) JobType {
+    t := NamedJobType(name)
+    r.RegisterBatch(pool, t, h)
+    return t
+}
+
+func (r *Registry) lookupBatch(pool Pool, t JobType) (BatchHandler, bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    h, ok := r.batch[pool][t]
+    return h, ok
+}
+
+// RegisterName registers h on pool for NamedJobType(name) and returns that
+// type for use in Job.Type.
+func (r *Registry) RegisterName(pool Pool, name string, h Handler) JobType {
+    t := NamedJobType(name)
+    r.Register(pool, t, h)
+    return t
+}
+
+func (r *Registry) lookup(pool Pool, t JobType) (Handler, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    if h, ok := r.handlers[pool][t]; ok {
+        return h, nil
+    }
+    return nil, &UnknownJobTypeError{Pool: pool, Type: t, Name: t.name()}
+}
+
+// handleFunc runs the Func carried by a JobTypeFunc job.
+func handleFunc(ctx context.Context, job Job) (interface{}, error) {
+    fn, ok := job.Input.(Func)
+    if !ok {
+        return nil, fmt.Errorf("worker: job tx=%s: input is %T, want worker.Func", job.TxID, job.Input)
+    }
+    return fn(ctx)
+}
+
+func handleEcho(ctx context.Context, job Job) (interface{}, error) {
+    return job.Input, nil
+}
+
+// handleMainPipeline is the example main-pool handler: it fans the input
+// out to the db and external pools, whose example handlers echo it back, and
+// returns the db copy once both have answered.
+func handleMainPipeline(ctx context.Context, job Job) (interface{}, error) {
+    f := NewFanout(ctx, job)
+    f.Go(PoolDB, JobTypeExample, job.Input)
+    f.Go(PoolExternal, JobTypeExample, job.Input)
+    out, err := f.Wait()
+    if err != nil {
+        return nil, err
+    }
+    return out[0], nil
+}
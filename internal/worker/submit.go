@@ -0,0 +1,121 @@
+// # 제네릭 작업 제출 (큐 대기 제한 + 결과 대기 + 타입 변환)
+package worker
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/metrics"
+    "github.com/example/XXXDONGXXX/internal/txid"
+)
+
+var (
+    // ErrBusy means the pool's queue stayed full for EnqueueTimeout.
+    ErrBusy = errors.New("worker: queue full")
+    // ErrTimeout means ctx's deadline passed before the job finished.
+    ErrTimeout = errors.New("worker: timed out")
+)
+
+// EnqueueTimeout bounds how long Submit waits for room in a full queue.
+const EnqueueTimeout = 100 * time.Millisecond
+
+// Submit sends in to pool as a job of type typ and waits for the handler's
+// result, which must be an Out (or nil for the zero Out). The job carries
+// ctx, its txid and its priority (see WithPriority). It fails with ErrBusy
+// when the queue is full, a *CircuitOpenError while the job's target is
+// unavailable, ErrShutdown once the pools are shutting down, ErrTimeout
+// when ctx's deadline passes and ctx.Err() when ctx is cancelled.
+func Submit[In, Out any](ctx context.Context, pools *Pools, pool Pool, typ JobType, in In) (Out, error) {
+    var zero Out
+    res := make(chan Result, 1)
+    job := Job{
+        Type:     typ,
+        TxID:     txid.FromContext(ctx),
+        Ctx:      ctx,
+        Input:    in,
+        Result:   res,
+        Priority: PriorityFromContext(ctx),
+    }
+    if err := pools.enqueue(ctx, pool, job, EnqueueTimeout); err != nil {
+        return zero, err
+    }
+
+    select {
+    case r := <-res:
+        if r.Err != nil {
+            return zero, r.Err
+        }
+        if r.Data == nil {
+            return zero, nil
+        }
+        out, ok := r.Data.(Out)
+        if !ok {
+            return zero, fmt.Errorf("worker: job tx=%s: result is %T, want %T", job.TxID, r.Data, zero)
+        }
+        return out, nil
+    case <-ctx.Done():
+        return zero, ctxErr(ctx)
+    }
+}
+
+// Enqueue puts job on pool's queue, on the main pool the lane for
+// job.Priority, and waits while the queue is full until ctx ends. Results
+// arrive on job.Result as usual.
+func (p *Pools) Enqueue(ctx context.Context, pool Pool, job Job) error {
+    return p.enqueue(ctx, pool, job, -1)
+}
+
+// enqueue gives up with ErrBusy after wait; a negative wait means no limit.
+func (p *Pools) enqueue(ctx context.Context, pool Pool, job Job, wait time.Duration) error {
+    in, err := p.lane(pool, job.Priority)
+    if err != nil {
+        return err
+    }
+    p.mu.Lock()
+    done := p.doneLocked(pool)
+    p.mu.Unlock()
+    select {
+    case <-done:
+        return ErrShutdown
+    default:
+    }
+    if br := p.breaker(pool, job); br != nil && br.Rejecting() {
+        metrics.IncBreakerRejected(br.target)
+        return &CircuitOpenError{Target: br.target}
+    }
+    if job.EnqueuedAt.IsZero() {
+        job.EnqueuedAt = time.Now()
+    }
+
+    select {
+    case in <- job:
+        return nil
+    default:
+    }
+    var timeout <-chan time.Time
+    if wait >= 0 {
+        t := time.NewTimer(wait)
+        defer t.Stop()
+        timeout = t.C
+    }
+    select {
+    case in <- job:
+        return nil
+    case <-timeout:
+        metrics.IncWorkerRejected(string(pool), job.Type.String())
+        return ErrBusy
+    case <-done:
+        return ErrShutdown
+    case <-ctx.Done():
+        return ctxErr(ctx)
+    }
+}
+
+func ctxErr(ctx context.Context) error {
+    if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+        return fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+    }
+    return ctx.Err()
+}
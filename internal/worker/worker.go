@@ -2,9 +2,15 @@ package worker
 
 import (
     "context"
+    "errors"
+    "fmt"
+    "runtime/debug"
+    "sync"
+    "sync/atomic"
     "time"
 
     "github.com/example/XXXDONGXXX/internal/logger"
+    "github.com/example/XXXDONGXXX/internal/metrics"
     "github.com/example/XXXDONGXXX/internal/txid"
 )
 
@@ -12,14 +18,44 @@ type JobType int
 
 const (
     JobTypeExample JobType = iota
+    // JobTypeFunc runs Input, which must be a Func, on whichever pool the
+    // job was sent to.
+    JobTypeFunc
+)
+
+// Func is the Input of a JobTypeFunc job. It is called with Job.Ctx.
+type Func func(ctx context.Context) (interface{}, error)
+
+// Pool names one of the worker pools.
+type Pool string
+
+const (
+    PoolMain     Pool = "main"
+    PoolDB       Pool = "db"
+    PoolExternal Pool = "external"
 )
 
 type Job struct {
+    // ID identifies jobs on queues that track them, such as FileQueue.
+    ID     string
     Type   JobType
     TxID   string
     Ctx    context.Context
     Input  interface{}
     Result chan Result
+    // EnqueuedAt is set by the sender and feeds the queue wait metric.
+    EnqueuedAt time.Time
+    // Priority picks the main pool lane; see Pools.Enqueue.
+    Priority Priority
+    // OnStart, when set, is called by the worker right before the handler.
+    OnStart func()
+    // Target names the downstream the job calls, for its circuit breaker and
+    // rate limiter (see Pools.Breakers and Pools.Limiters). External pool
+    // jobs default to their type name.
+    Target string
+
+    // set by the worker for NewFanout
+    pools *Pools
 }
 
 type Result struct {
@@ -27,83 +63,522 @@ type Result struct {
     Err  error
 }
 
+// ErrShutdown is the Result.Err of jobs still queued when Pools.Shutdown
+// runs.
+var ErrShutdown = errors.New("worker: pools shut down")
+
+// PanicError is the Result.Err of a job whose handler panicked.
+type PanicError struct {
+    Value interface{}
+    Stack []byte
+}
+
+func (e *PanicError) Error() string {
+    return fmt.Sprintf("worker: handler panicked: %v", e.Value)
+}
+
 type Pools struct {
-    MainInput chan Job
-    DBInput   chan Job
-    ExtInput  chan Job
+    // MainInput is the normal-priority lane of the main pool. The high and
+    // low lanes are optional; without them every job uses MainInput.
+    MainInput     chan Job
+    MainHighInput chan Job
+    MainLowInput  chan Job
+    DBInput       chan Job
+    ExtInput      chan Job
+    // Registry holds the job handlers; nil means DefaultRegistry.
+    Registry *Registry
+    // Async holds optional per-pool queues for fire-and-forget jobs sent
+    // with EnqueueAsync. A FileQueue keeps them across restarts.
+    Async map[Pool]Queue
+    // Breakers, when set, guards jobs with a target (see Job.Target): they
+    // fail with a *CircuitOpenError while the target's breaker is open.
+    Breakers *Breakers
+    // Limiters, when set, makes jobs wait for a token of their target's
+    // rate limiter, failing with a *RateLimitError past the wait budget.
+    Limiters *Limiters
+    // Batch turns on batching per pool; see BatchConfig.
+    Batch map[Pool]BatchConfig
+
+    mu     sync.Mutex
+    groups map[Pool]*group
+    // per pool, so main handlers can still fan out while main shuts down
+    closing map[Pool]chan struct{}
+    wg      map[Pool]*sync.WaitGroup
+}
+
+// group is the set of workers serving one pool.
+type group struct {
+    ctx context.Context
+    log *logger.Logger
+    // indexed by Priority then laneAsync; single-queue pools only fill
+    // PriorityNormal and laneAsync
+    lanes [numLanes]<-chan Job
+    async Queue
+    batch BatchConfig
+    // one quit channel per live worker, newest last
+    quits  []chan struct{}
+    nextID int
+    busy   atomic.Int32
+}
+
+// Input returns the channel feeding pool, the normal lane for the main pool.
+func (p *Pools) Input(pool Pool) (chan Job, error) {
+    switch pool {
+    case PoolMain:
+        return p.MainInput, nil
+    case PoolDB:
+        return p.DBInput, nil
+    case PoolExternal:
+        return p.ExtInput, nil
+    }
+    return nil, fmt.Errorf("worker: unknown pool %q", pool)
+}
+
+// lane returns the channel a job of priority prio is queued on.
+func (p *Pools) lane(pool Pool, prio Priority) (chan Job, error) {
+    if pool == PoolMain {
+        switch {
+        case prio == PriorityHigh && p.MainHighInput != nil:
+            return p.MainHighInput, nil
+        case prio == PriorityLow && p.MainLowInput != nil:
+            return p.MainLowInput, nil
+        }
+    }
+    in, err := p.Input(pool)
+    if err == nil && in == nil {
+        err = fmt.Errorf("worker: %s pool has no queue", pool)
+    }
+    return in, err
+}
+
+func (p *Pools) registry() *Registry {
+    if p.Registry != nil {
+        return p.Registry
+    }
+    return DefaultRegistry
+}
+
+// doneLocked returns the channel closed when pool starts shutting down.
+// Caller holds p.mu.
+func (p *Pools) doneLocked(pool Pool) chan struct{} {
+    if p.closing == nil {
+        p.closing = make(map[Pool]chan struct{})
+    }
+    if p.closing[pool] == nil {
+        p.closing[pool] = make(chan struct{})
+    }
+    return p.closing[pool]
+}
+
+// wgLocked returns the WaitGroup of pool's workers. Caller holds p.mu.
+func (p *Pools) wgLocked(pool Pool) *sync.WaitGroup {
+    if p.wg == nil {
+        p.wg = make(map[Pool]*sync.WaitGroup)
+    }
+    if p.wg[pool] == nil {
+        p.wg[pool] = new(sync.WaitGroup)
+    }
+    return p.wg[pool]
+}
+
+// Shutdown stops the workers once their current job is finished, waits for
+// them, then fails every job left in the queues with ErrShutdown. The main
+// pool stops first; the db and external pools keep serving until its
+// workers are gone, so sub-jobs of a Fanout still in flight get handled.
+// Stop the producers (HTTP server, scheduler) first: jobs sent afterwards
+// are never picked up. If ctx ends first every pool is told to stop, the
+// queues are left as they are and ctx.Err() is returned; cancel the
+// workers' ctx to abort running handlers.
+func (p *Pools) Shutdown(ctx context.Context) error {
+    for _, stage := range [][]Pool{{PoolMain}, {PoolDB, PoolExternal}} {
+        if err := waitAll(ctx, p.signal(stage...)); err != nil {
+            p.signal(PoolDB, PoolExternal)
+            return err
+        }
+    }
+
+    for _, in := range []chan Job{p.MainHighInput, p.MainInput, p.MainLowInput, p.DBInput, p.ExtInput} {
+        drain(in)
+    }
+    var errs []error
+    for pool, q := range p.Async {
+        if err := q.Close(); err != nil {
+            errs = append(errs, fmt.Errorf("close %s async queue: %w", pool, err))
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// signal closes the done channels of pools and returns their workers'
+// WaitGroups.
+func (p *Pools) signal(pools ...Pool) []*sync.WaitGroup {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    var wgs []*sync.WaitGroup
+    for _, pool := range pools {
+        done := p.doneLocked(pool)
+        select {
+        case <-done:
+        default:
+            close(done)
+        }
+        wgs = append(wgs, p.wgLocked(pool))
+    }
+    return wgs
+}
+
+// waitAll waits for wgs until ctx ends.
+func waitAll(ctx context.Context, wgs []*sync.WaitGroup) error {
+    idle := make(chan struct{})
+    go func() {
+        for _, wg := range wgs {
+            wg.Wait()
+        }
+        close(idle)
+    }()
+    select {
+    case <-idle:
+        return nil
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
+func drain(in chan Job) {
+    for {
+        select {
+        case job := <-in:
+            if job.Result != nil {
+                job.Result <- Result{Err: ErrShutdown}
+            }
+        default:
+            return
+        }
+    }
 }
 
 func StartMainWorkers(ctx context.Context, count int, pools *Pools, log *logger.Logger) {
+    var lanes [numLanes]<-chan Job
+    lanes[PriorityNormal] = pools.MainInput
+    lanes[PriorityHigh] = pools.MainHighInput
+    lanes[PriorityLow] = pools.MainLowInput
+    pools.start(ctx, PoolMain, lanes, count, log)
+}
+
+func StartDBWorkers(ctx context.Context, count int, pools *Pools, log *logger.Logger) {
+    var lanes [numLanes]<-chan Job
+    lanes[PriorityNormal] = pools.DBInput
+    pools.start(ctx, PoolDB, lanes, count, log)
+}
+
+func StartExternalWorkers(ctx context.Context, count int, pools *Pools, log *logger.Logger) {
+    var lanes [numLanes]<-chan Job
+    lanes[PriorityNormal] = pools.ExtInput
+    pools.start(ctx, PoolExternal, lanes, count, log)
+}
+
+func (p *Pools) start(ctx context.Context, pool Pool, lanes [numLanes]<-chan Job, count int, log *logger.Logger) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.groups == nil {
+        p.groups = make(map[Pool]*group)
+    }
+    g := &group{ctx: ctx, log: log, lanes: lanes, batch: p.Batch[pool]}
+    if q := p.Async[pool]; q != nil {
+        g.async = q
+        g.lanes[laneAsync] = q.C()
+    }
+    p.groups[pool] = g
+    p.grow(pool, g, count)
+}
+
+// Resize changes the number of workers serving pool. Removed workers finish
+// the job they are running before they exit.
+func (p *Pools) Resize(pool Pool, n int) error {
+    if n < 1 {
+        return fmt.Errorf("worker: %s pool needs at least one worker", pool)
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    g := p.groups[pool]
+    if g == nil {
+        return fmt.Errorf("worker: %s pool not started", pool)
+    }
+    select {
+    case <-p.doneLocked(pool):
+        return ErrShutdown
+    default:
+    }
+
+    old := len(g.quits)
+    switch {
+    case n > old:
+        p.grow(pool, g, n-old)
+    case n < old:
+        for _, quit := range g.quits[n:] {
+            close(quit)
+        }
+        g.quits = g.quits[:n]
+    default:
+        return nil
+    }
+    g.log.Infof("%s pool resized from %d to %d workers", pool, old, n)
+    return nil
+}
+
+// Size returns the number of workers serving pool.
+func (p *Pools) Size(pool Pool) int {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if g := p.groups[pool]; g != nil {
+        return len(g.quits)
+    }
+    return 0
+}
+
+// Stats reports queue and worker counts of every started pool, for the
+// metrics endpoint.
+func (p *Pools) Stats() []metrics.PoolStats {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    stats := make([]metrics.PoolStats, 0, len(p.groups))
+    for pool, g := range p.groups {
+        st := metrics.PoolStats{
+            Pool:    string(pool),
+            Workers: len(g.quits),
+            Busy:    int(g.busy.Load()),
+        }
+        for _, in := range g.lanes[:laneAsync] {
+            st.QueueLen += len(in)
+            st.QueueCap += cap(in)
+        }
+        if g.async != nil {
+            st.QueueLen += g.async.Len()
+            st.QueueCap += g.async.Cap()
+        }
+        stats = append(stats, st)
+    }
+    return stats
+}
+
+// grow starts count more workers for pool. Caller holds p.mu.
+func (p *Pools) grow(pool Pool, g *group, count int) {
+    done, wg := p.doneLocked(pool), p.wgLocked(pool)
     for i := 0; i < count; i++ {
-        go func(id int) {
-            log.Infof("main worker %d started", id)
-            for {
-                select {
-                case <-ctx.Done():
-                    log.Infof("main worker %d stopping", id)
-                    return
-                case job := <-pools.MainInput:
-                    handleMainJob(ctx, log, pools, job)
-                }
-            }
-        }(i)
+        quit := make(chan struct{})
+        g.quits = append(g.quits, quit)
+        wg.Add(1)
+        go p.work(g, pool, g.nextID, wg, done, quit)
+        g.nextID++
+    }
+}
+
+func (p *Pools) work(g *group, pool Pool, id int, wg *sync.WaitGroup, done, quit <-chan struct{}) {
+    defer wg.Done()
+    g.log.Infof("%s worker %d started", pool, id)
+    turn := 0
+    // checked before every receive so a ready job never delays stopping
+    for !stopped(g.ctx, done, quit) {
+        job, lane, ok := g.take(&turn, done, quit, nil)
+        if !ok {
+            break
+        }
+        g.busy.Add(1)
+        p.dispatch(g, pool, job, lane, &turn, done, quit)
+        g.busy.Add(-1)
     }
+    g.log.Infof("%s worker %d stopping", pool, id)
 }
 
-func handleMainJob(ctx context.Context, log *logger.Logger, pools *Pools, job Job) {
-    tx := job.TxID
-    if tx == "" {
-        tx = txid.NewID()
+// dispatch handles job, together with the jobs that follow it when its type
+// has a batch handler. A job of another type that ends a batch is
+// dispatched in turn.
+func (p *Pools) dispatch(g *group, pool Pool, job Job, lane int, turn *int, done, quit <-chan struct{}) {
+    for {
+        h, ok := p.registry().lookupBatch(pool, job.Type)
+        if !ok || g.batch.MaxItems <= 1 {
+            p.handleOne(g, pool, job, lane)
+            return
+        }
+        if job, lane, ok = p.handleBatch(g, pool, h, job, lane, turn, done, quit); !ok {
+            return
+        }
     }
-    log.Debugf("handling main job type=%d tx=%s", job.Type, tx)
-    // simple example: echo input with small delay
+}
+
+// handleOne handles a single job and settles it if it came from the async
+// queue.
+func (p *Pools) handleOne(g *group, pool Pool, job Job, lane int) {
+    err := handleJob(g.ctx, g.log, p, pool, job)
+    if lane == laneAsync {
+        g.settle(pool, job, err)
+    }
+}
+
+// settle reports the outcome of an async job to its queue.
+func (g *group) settle(pool Pool, job Job, err error) {
+    if serr := g.async.Settle(job, err); serr != nil {
+        g.log.Errorf("%s async job %s tx=%s: %v", pool, job.ID, job.TxID, serr)
+    }
+}
+
+func stopped(ctx context.Context, done, quit <-chan struct{}) bool {
     select {
     case <-ctx.Done():
-        return
-    case <-time.After(10 * time.Millisecond):
+        return true
+    case <-done:
+        return true
+    case <-quit:
+        return true
+    default:
+        return false
+    }
+}
+
+// handleJob runs the handler registered for job.Type on pool, sends the
+// outcome to job.Result and returns the handler's error. Jobs whose Job.Ctx
+// ended while they were queued are not run; their Result.Err is the context
+// error (wrapped in ErrTimeout for deadlines).
+func handleJob(ctx context.Context, log *logger.Logger, pools *Pools, pool Pool, job Job) error {
+    if job.TxID == "" {
+        job.TxID = txid.NewID()
+    }
+    start := time.Now()
+    wait := queueWait(job, start)
+    if job.Ctx != nil && job.Ctx.Err() != nil {
+        return expire(log, pool, job, wait)
+    }
+    log.Debugf("handling %s job type=%s tx=%s", pool, job.Type, job.TxID)
+    ctx, cancel := jobContext(ctx, job)
+    defer cancel()
+    // looked up first: a missing handler says nothing about the target
+    h, err := pools.registry().lookup(pool, job.Type)
+    if err != nil {
+        log.Errorf("%v (tx=%s)", err, job.TxID)
+        metrics.ObserveWorkerJob(string(pool), job.Type.String(), false, wait, time.Since(start))
+        if job.Result != nil {
+            job.Result <- Result{Err: err}
+        }
+        return err
+    }
+    br, err := pools.admit(ctx, pool, job)
+    if err != nil {
+        log.Infof("%s job type=%s tx=%s rejected: %v", pool, job.Type, job.TxID, err)
+        if job.Result != nil {
+            job.Result <- Result{Err: err}
+        }
+        return err
     }
 
+    if job.OnStart != nil {
+        job.OnStart()
+    }
+    job.pools = pools
+    var res Result
+    res.Data, res.Err = callHandler(ctx, log, pool, h, job)
+    if br != nil {
+        br.Record(breakerFailure(res.Err))
+    }
+    metrics.ObserveWorkerJob(string(pool), job.Type.String(), res.Err == nil, wait, time.Since(start))
     if job.Result != nil {
-        job.Result <- Result{Data: job.Input, Err: nil}
+        job.Result <- res
     }
+    return res.Err
 }
 
-func StartDBWorkers(ctx context.Context, count int, pools *Pools, log *logger.Logger) {
-    for i := 0; i < count; i++ {
-        go func(id int) {
-            log.Infof("db worker %d started", id)
-            for {
-                select {
-                case <-ctx.Done():
-                    log.Infof("db worker %d stopping", id)
-                    return
-                case job := <-pools.DBInput:
-                    log.Debugf("handling db job type=%d tx=%s", job.Type, job.TxID)
-                    if job.Result != nil {
-                        job.Result <- Result{Data: job.Input, Err: nil}
-                    }
-                }
-            }
-        }(i)
+// queueWait is how long job sat in its queue until now, zero when unknown.
+func queueWait(job Job, now time.Time) time.Duration {
+    if job.EnqueuedAt.IsZero() {
+        return 0
     }
+    return now.Sub(job.EnqueuedAt)
 }
 
-func StartExternalWorkers(ctx context.Context, count int, pools *Pools, log *logger.Logger) {
-    for i := 0; i < count; i++ {
-        go func(id int) {
-            log.Infof("external worker %d started", id)
-            for {
-                select {
-                case <-ctx.Done():
-                    log.Infof("external worker %d stopping", id)
-                    return
-                case job := <-pools.ExtInput:
-                    log.Debugf("handling external job type=%d tx=%s", job.Type, job.TxID)
-                    if job.Result != nil {
-                        job.Result <- Result{Data: job.Input, Err: nil}
-                    }
-                }
+// expire fails a job whose Job.Ctx ended while it was queued.
+func expire(log *logger.Logger, pool Pool, job Job, wait time.Duration) error {
+    err := ctxErr(job.Ctx)
+    log.Infof("%s job type=%s tx=%s expired in queue after %s: %v", pool, job.Type, job.TxID, wait, err)
+    metrics.IncWorkerExpired(string(pool), job.Type.String())
+    if job.Result != nil {
+        job.Result <- Result{Err: err}
+    }
+    return err
+}
+
+// admit applies the circuit breaker and rate limiter of job's target,
+// waiting for a token if needed. The returned breaker, if any, must be told
+// the job's outcome.
+func (p *Pools) admit(ctx context.Context, pool Pool, job Job) (*Breaker, error) {
+    target := jobTarget(pool, job)
+    if target == "" {
+        return nil, nil
+    }
+    br := p.breaker(pool, job)
+    // checked before waiting so that an open circuit fails fast
+    if br != nil && br.Rejecting() {
+        metrics.IncBreakerRejected(target)
+        return nil, &CircuitOpenError{Target: target}
+    }
+    if p.Limiters != nil {
+        if l := p.Limiters.Get(target); l != nil {
+            if err := l.Wait(ctx); err != nil {
+                return nil, err
             }
-        }(i)
+        }
+    }
+    if br != nil {
+        if err := br.Allow(); err != nil {
+            return nil, err
+        }
+    }
+    return br, nil
+}
+
+// breaker returns the breaker of job's target, nil when it has none.
+func (p *Pools) breaker(pool Pool, job Job) *Breaker {
+    if p.Breakers == nil {
+        return nil
+    }
+    return p.Breakers.Get(jobTarget(pool, job))
+}
+
+// jobTarget names the downstream job calls: Job.Target or, on the external
+// pool, the job type name. Func jobs have no target of their own.
+func jobTarget(pool Pool, job Job) string {
+    if job.Target != "" || pool != PoolExternal || job.Type == JobTypeFunc {
+        return job.Target
     }
+    return job.Type.String()
+}
+
+// jobContext returns the context handlers run with: Job.Ctx, also cancelled
+// when the worker's ctx ends, carrying the job's txid.
+func jobContext(workerCtx context.Context, job Job) (context.Context, context.CancelFunc) {
+    if job.Ctx == nil {
+        return context.WithCancel(txid.WithTxID(workerCtx, job.TxID))
+    }
+    ctx := job.Ctx
+    if txid.FromContext(ctx) == "" {
+        ctx = txid.WithTxID(ctx, job.TxID)
+    }
+    ctx, cancel := context.WithCancel(ctx)
+    stop := context.AfterFunc(workerCtx, cancel)
+    return ctx, func() {
+        stop()
+        cancel()
+    }
+}
+
+// callHandler runs h, turning a panic into a *PanicError so that the worker
+// survives and the caller still gets a Result.
+func callHandler(ctx context.Context, log *logger.Logger, pool Pool, h Handler, job Job) (data interface{}, err error) {
+    defer func() {
+        if rec := recover(); rec != nil {
+            stack := debug.Stack()
+            log.Criticalf("panic in %s worker tx=%s type=%s: %v\n%s", pool, job.TxID, job.Type, rec, stack)
+            metrics.IncWorkerPanic(string(pool), job.Type.String())
+            data, err = nil, &PanicError{Value: rec, Stack: stack}
+        }
+    }()
+    return h(ctx, job)
 }
@@ -0,0 +1,394 @@
+package worker
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/example/XXXDONGXXX/internal/logger"
+    "github.com/example/XXXDONGXXX/internal/txid"
+)
+
+func startTestPools(t *testing.T, reg *Registry) *Pools {
+    t.Helper()
+    lg, err := logger.New(t.TempDir(), "debug")
+    if err != nil {
+        t.Fatalf("logger: %v", err)
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    pools := &Pools{
+        MainInput: make(chan Job, 1),
+        DBInput:   make(chan Job, 1),
+        ExtInput:  make(chan Job, 1),
+        Registry:  reg,
+    }
+    StartMainWorkers(ctx, 1, pools, lg)
+    StartDBWorkers(ctx, 1, pools, lg)
+    StartExternalWorkers(ctx, 1, pools, lg)
+    t.Cleanup(func() {
+        cancel()
+        pools.Shutdown(context.Background())
+        lg.Close()
+    })
+    return pools
+}
+
+func send(pools *Pools, pool Pool, typ JobType, in interface{}) Result {
+    ch, _ := pools.Input(pool)
+    res := make(chan Result, 1)
+    ch <- Job{Type: typ, TxID: "tx", Ctx: context.Background(), Input: in, Result: res}
+    return <-res
+}
+
+func TestRegistryDispatch(t *testing.T) {
+    reg := NewRegistry()
+    upper := reg.RegisterName(PoolDB, "upper", HandlerFunc(func(ctx context.Context, s string) (string, error) {
+        return strings.ToUpper(s), nil
+    }))
+    pools := startTestPools(t, reg)
+
+    if res := send(pools, PoolDB, upper, "abc"); res.Err != nil || res.Data != "ABC" {
+        t.Fatalf("upper on db = %v, %v", res.Data, res.Err)
+    }
+    if res := send(pools, PoolDB, upper, 42); res.Err == nil {
+        t.Fatal("want error for wrong input type")
+    }
+    if res := send(pools, PoolMain, JobTypeExample, "echo"); res.Err != nil || res.Data != "echo" {
+        t.Fatalf("example on main = %v, %v", res.Data, res.Err)
+    }
+
+    // registered on db only
+    res := send(pools, PoolExternal, upper, "abc")
+    var unknown *UnknownJobTypeError
+    if !errors.As(res.Err, &unknown) {
+        t.Fatalf("err = %v, want UnknownJobTypeError", res.Err)
+    }
+    if unknown.Pool != PoolExternal || unknown.Type != upper || unknown.Name != "upper" {
+        t.Fatalf("unexpected error fields %+v", unknown)
+    }
+}
+
+func TestSubmit(t *testing.T) {
+    reg := NewRegistry()
+    length := reg.RegisterName(PoolMain, "len", HandlerFunc(func(ctx context.Context, s string) (int, error) {
+        return len(s), nil
+    }))
+    block := reg.RegisterName(PoolMain, "block", func(ctx context.Context, job Job) (interface{}, error) {
+        <-job.Ctx.Done()
+        return nil, job.Ctx.Err()
+    })
+    pools := startTestPools(t, reg)
+
+    n, err := Submit[string, int](context.Background(), pools, PoolMain, length, "abcd")
+    if err != nil || n != 4 {
+        t.Fatalf("Submit = %d, %v; want 4", n, err)
+    }
+    if _, err := Submit[string, string](context.Background(), pools, PoolMain, length, "abcd"); err == nil {
+        t.Fatal("want error for wrong result type")
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    if _, err := Submit[string, int](ctx, pools, PoolMain, block, ""); !errors.Is(err, ErrTimeout) {
+        t.Fatalf("err = %v, want ErrTimeout", err)
+    }
+}
+
+func TestSubmitBusy(t *testing.T) {
+    // no workers: the single slot fills up and stays full
+    pools := &Pools{DBInput: make(chan Job, 1)}
+    pools.DBInput <- Job{}
+    if _, err := Submit[string, string](context.Background(), pools, PoolDB, JobTypeExample, "x"); !errors.Is(err, ErrBusy) {
+        t.Fatalf("err = %v, want ErrBusy", err)
+    }
+
+    if err := pools.Shutdown(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := Submit[string, string](context.Background(), pools, PoolDB, JobTypeExample, "x"); !errors.Is(err, ErrShutdown) {
+        t.Fatalf("after Shutdown err = %v, want ErrShutdown", err)
+    }
+}
+
+func TestPriorityLanes(t *testing.T) {
+    reg := NewRegistry()
+    var mu sync.Mutex
+    var order []Priority
+    record := reg.RegisterName(PoolMain, "record", func(ctx context.Context, job Job) (interface{}, error) {
+        mu.Lock()
+        order = append(order, job.Priority)
+        mu.Unlock()
+        return nil, nil
+    })
+    lg, err := logger.New(t.TempDir(), "debug")
+    if err != nil {
+        t.Fatalf("logger: %v", err)
+    }
+    defer lg.Close()
+    pools := &Pools{
+        MainInput:     make(chan Job, 14),
+        MainHighInput: make(chan Job, 14),
+        MainLowInput:  make(chan Job, 14),
+        Registry:      reg,
+    }
+    // back every lane up before the single worker starts
+    results := make(chan Result, 42)
+    for _, pr := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+        for i := 0; i < 14; i++ {
+            job := Job{Type: record, Priority: pr, Result: results}
+            if err := pools.Enqueue(context.Background(), PoolMain, job); err != nil {
+                t.Fatal(err)
+            }
+        }
+    }
+    StartMainWorkers(context.Background(), 1, pools, lg)
+    for i := 0; i < 42; i++ {
+        <-results
+    }
+    if err := pools.Shutdown(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+
+    // the first 14 jobs follow the 4:2:1 weights, then high runs dry
+    count := map[Priority]int{}
+    for _, pr := range order[:14] {
+        count[pr]++
+    }
+    if count[PriorityHigh] != 8 || count[PriorityNormal] != 4 || count[PriorityLow] != 2 {
+        t.Fatalf("first 14 jobs by priority = %v, want high 8, normal 4, low 2", count)
+    }
+}
+
+func TestAsyncLaneShare(t *testing.T) {
+    reg := NewRegistry()
+    var mu sync.Mutex
+    var order []string
+    record := func(name string) Handler {
+        return func(ctx context.Context, job Job) (interface{}, error) {
+            mu.Lock()
+            order = append(order, name)
+            mu.Unlock()
+            return nil, nil
+        }
+    }
+    syncType := reg.RegisterName(PoolMain, "sync", record("sync"))
+    asyncType := reg.RegisterName(PoolMain, "async", record("async"))
+    lg, err := logger.New(t.TempDir(), "debug")
+    if err != nil {
+        t.Fatalf("logger: %v", err)
+    }
+    defer lg.Close()
+    q := ChanQueue(make(chan Job, 16))
+    pools := &Pools{
+        MainInput:     make(chan Job, 16),
+        MainHighInput: make(chan Job, 16),
+        Registry:      reg,
+        Async:         map[Pool]Queue{PoolMain: q},
+    }
+    results := make(chan Result, 16)
+    for i := 0; i < 16; i++ {
+        if err := pools.Enqueue(context.Background(), PoolMain, Job{Type: syncType, Priority: PriorityHigh, Result: results}); err != nil {
+            t.Fatal(err)
+        }
+        if err := pools.EnqueueAsync(context.Background(), PoolMain, Job{Type: asyncType}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    StartMainWorkers(context.Background(), 1, pools, lg)
+    for i := 0; i < 16; i++ {
+        <-results
+    }
+    if err := pools.Shutdown(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+
+    // with normal and low empty, async still gets one slot in eight
+    count := map[string]int{}
+    for _, name := range order[:16] {
+        count[name]++
+    }
+    if count["sync"] != 14 || count["async"] != 2 {
+        t.Fatalf("first 16 jobs = %v, want sync 14, async 2", count)
+    }
+}
+
+func TestPoolsShutdown(t *testing.T) {
+    reg := NewRegistry()
+    started := make(chan struct{})
+    release := make(chan struct{})
+    slow := reg.RegisterName(PoolMain, "slow", func(ctx context.Context, job Job) (interface{}, error) {
+        close(started)
+        <-release
+        return "done", nil
+    })
+    pools := startTestPools(t, reg)
+
+    inflight := make(chan Result, 1)
+    pools.MainInput <- Job{Type: slow, Result: inflight}
+    <-started
+    queued := make(chan Result, 1)
+    pools.MainInput <- Job{Type: JobTypeExample, Result: queued}
+
+    shut := make(chan error, 1)
+    go func() { shut <- pools.Shutdown(context.Background()) }()
+    select {
+    case err := <-shut:
+        t.Fatalf("Shutdown returned %v with a job in flight", err)
+    case <-time.After(20 * time.Millisecond):
+    }
+
+    close(release)
+    if err := <-shut; err != nil {
+        t.Fatalf("Shutdown: %v", err)
+    }
+    if res := <-inflight; res.Err != nil || res.Data != "done" {
+        t.Fatalf("in-flight job = %v, %v", res.Data, res.Err)
+    }
+    if res := <-queued; !errors.Is(res.Err, ErrShutdown) {
+        t.Fatalf("queued job err = %v, want ErrShutdown", res.Err)
+    }
+}
+
+func TestPoolsShutdownTimeout(t *testing.T) {
+    reg := NewRegistry()
+    started := make(chan struct{})
+    block := reg.RegisterName(PoolDB, "block", func(ctx context.Context, job Job) (interface{}, error) {
+        close(started)
+        <-ctx.Done()
+        return nil, ctx.Err()
+    })
+    pools := startTestPools(t, reg)
+    pools.DBInput <- Job{Type: block}
+    <-started
+
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    if err := pools.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("Shutdown = %v, want deadline exceeded", err)
+    }
+}
+
+func TestWorkerSurvivesPanic(t *testing.T) {
+    reg := NewRegistry()
+    boom := reg.RegisterName(PoolExternal, "boom", func(ctx context.Context, job Job) (interface{}, error) {
+        panic("boom")
+    })
+    pools := startTestPools(t, reg)
+
+    res := send(pools, PoolExternal, boom, nil)
+    var perr *PanicError
+    if !errors.As(res.Err, &perr) || perr.Value != "boom" || len(perr.Stack) == 0 {
+        t.Fatalf("err = %v, want PanicError with stack", res.Err)
+    }
+    // the only external worker is still there
+    if res := send(pools, PoolExternal, JobTypeExample, "ok"); res.Err != nil || res.Data != "ok" {
+        t.Fatalf("after panic = %v, %v", res.Data, res.Err)
+    }
+}
+
+func TestPoolsResize(t *testing.T) {
+    reg := NewRegistry()
+    var running, peak atomic.Int32
+    release := make(chan struct{})
+    wait := reg.RegisterName(PoolMain, "wait", func(ctx context.Context, job Job) (interface{}, error) {
+        n := running.Add(1)
+        defer running.Add(-1)
+        for {
+            p := peak.Load()
+            if n <= p || peak.CompareAndSwap(p, n) {
+                break
+            }
+        }
+        <-release
+        return nil, nil
+    })
+    pools := startTestPools(t, reg)
+
+    if err := pools.Resize(PoolMain, 3); err != nil {
+        t.Fatal(err)
+    }
+    if got := pools.Size(PoolMain); got != 3 {
+        t.Fatalf("Size = %d, want 3", got)
+    }
+    results := make(chan Result, 3)
+    for i := 0; i < 3; i++ {
+        pools.MainInput <- Job{Type: wait, Result: results}
+    }
+    for running.Load() < 3 {
+        time.Sleep(time.Millisecond)
+    }
+
+    // shrinking lets the running jobs finish
+    if err := pools.Resize(PoolMain, 1); err != nil {
+        t.Fatal(err)
+    }
+    close(release)
+    for i := 0; i < 3; i++ {
+        if res := <-results; res.Err != nil {
+            t.Fatalf("job %d: %v", i, res.Err)
+        }
+    }
+    if peak.Load() != 3 || pools.Size(PoolMain) != 1 {
+        t.Fatalf("peak = %d, size = %d; want 3 and 1", peak.Load(), pools.Size(PoolMain))
+    }
+    if res := send(pools, PoolMain, JobTypeExample, "ok"); res.Err != nil {
+        t.Fatalf("after shrink: %v", res.Err)
+    }
+    if err := pools.Resize(PoolMain, 0); err == nil {
+        t.Fatal("want error for zero workers")
+    }
+}
+
+func TestExpiredInQueue(t *testing.T) {
+    reg := NewRegistry()
+    started := make(chan struct{})
+    release := make(chan struct{})
+    slow := reg.RegisterName(PoolMain, "slow", func(ctx context.Context, job Job) (interface{}, error) {
+        close(started)
+        <-release
+        return nil, nil
+    })
+    var ran atomic.Int32
+    count := reg.RegisterName(PoolMain, "count", func(ctx context.Context, job Job) (interface{}, error) {
+        ran.Add(1)
+        return txid.FromContext(ctx), nil
+    })
+    lg, err := logger.New(t.TempDir(), "debug")
+    if err != nil {
+        t.Fatalf("logger: %v", err)
+    }
+    defer lg.Close()
+    pools := &Pools{MainInput: make(chan Job, 3), Registry: reg}
+    StartMainWorkers(context.Background(), 1, pools, lg)
+    defer pools.Shutdown(context.Background())
+
+    pools.MainInput <- Job{Type: slow}
+    <-started
+    cancelled, cancel := context.WithCancel(context.Background())
+    expired, cancel2 := context.WithTimeout(context.Background(), time.Millisecond)
+    defer cancel2()
+    results := make(chan Result, 3)
+    pools.MainInput <- Job{Type: count, Ctx: cancelled, Result: results}
+    pools.MainInput <- Job{Type: count, Ctx: expired, Result: results}
+    pools.MainInput <- Job{Type: count, TxID: "live", Ctx: context.Background(), Result: results}
+    cancel()
+    time.Sleep(5 * time.Millisecond)
+    close(release)
+
+    if res := <-results; !errors.Is(res.Err, context.Canceled) {
+        t.Fatalf("cancelled job err = %v", res.Err)
+    }
+    if res := <-results; !errors.Is(res.Err, ErrTimeout) {
+        t.Fatalf("expired job err = %v", res.Err)
+    }
+    if res := <-results; res.Err != nil || res.Data != "live" {
+        t.Fatalf("live job = %v, %v", res.Data, res.Err)
+    }
+    if n := ran.Load(); n != 1 {
+        t.Fatalf("handler ran %d times, want 1", n)
+    }
+}